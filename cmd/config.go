@@ -2,35 +2,56 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/hamzabow/co/internal/config"
+	"github.com/hamzabow/co/internal/prompts"
+	"github.com/hamzabow/co/internal/provider"
 	"github.com/spf13/cobra"
 )
 
 var (
-	apiKey     string
-	provider   string
-	showConfig bool
+	apiKey        string
+	keyProvider   string
+	defaultFormat string
+	showConfig    bool
 )
 
 // configCmd represents the config command
 var configCmd = &cobra.Command{
 	Use:   "config",
-	Short: "Configure API key settings",
-	Long: `Configure AI provider API keys for use with Co.
-You can set, update, or view your API key configuration.`,
+	Short: "Configure API keys and settings",
+	Long: `Configure AI provider API keys and settings for use with Co.
+You can set, update, or view your API key configuration, and manage the
+settings file with the get, set, unset and list subcommands.
+
+Settings are resolved in the following order, later sources taking precedence:
+defaults, the user settings file, the repository settings file (.co.toml or
+.co/config.toml at the git top-level), CO_* environment variables, and flags.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if showConfig {
 			displayConfiguration()
 			return
 		}
 
+		if defaultFormat != "" {
+			if err := saveDefaultFormat(defaultFormat); err != nil {
+				fmt.Printf("Error saving default format: %v\n", err)
+				return
+			}
+			fmt.Printf("Default format set to %s\n", defaultFormat)
+			if apiKey == "" {
+				return
+			}
+		}
+
 		if apiKey != "" {
-			if err := config.SaveAPIKey(provider, apiKey); err != nil {
+			if err := config.SaveAPIKey(keyProvider, apiKey); err != nil {
 				fmt.Printf("Error saving API key: %v\n", err)
 				return
 			}
-			fmt.Printf("API key for %s saved successfully\n", provider)
+			fmt.Printf("API key for %s saved successfully\n", keyProvider)
 		} else {
 			fmt.Println("No API key provided. Use --key to set an API key.")
 			fmt.Println("Use --show to display current configuration.")
@@ -38,11 +59,77 @@ You can set, update, or view your API key configuration.`,
 	},
 }
 
+// configGetCmd prints the effective value of a single setting
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print the effective value of a setting",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		settings, err := resolveSettings(nil)
+		if err != nil {
+			return err
+		}
+
+		value, err := settings.Get(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Println(value)
+		return nil
+	},
+}
+
+// configSetCmd stores a setting in the user settings file
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Store a setting in the user settings file",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := config.SetUserSetting(args[0], args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("%s set to %s\n", args[0], args[1])
+		return nil
+	},
+}
+
+// configUnsetCmd removes a setting from the user settings file
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset <key>",
+	Short: "Remove a setting from the user settings file",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := config.UnsetUserSetting(args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("%s unset\n", args[0])
+		return nil
+	},
+}
+
+// configListCmd lists every setting with its effective value and origin
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all settings with their effective values and origins",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		settings, err := resolveSettings(nil)
+		if err != nil {
+			return err
+		}
+
+		printSettings(settings)
+		return nil
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configGetCmd, configSetCmd, configUnsetCmd, configListCmd)
 
 	configCmd.Flags().StringVar(&apiKey, "key", "", "Set the API key for the selected provider")
-	configCmd.Flags().StringVar(&provider, "provider", ProviderOpenAI, "Set the AI provider (openai, anthropic, ollama)")
+	configCmd.Flags().StringVar(&keyProvider, "provider", ProviderOpenAI, "Set the AI provider (openai, anthropic, ollama)")
+	configCmd.Flags().StringVar(&defaultFormat, "format", "", "Set the default commit message format ("+strings.Join(prompts.Formats(), ", ")+", or a custom template name)")
 	configCmd.Flags().BoolVar(&showConfig, "show", false, "Show current configuration")
 }
 
@@ -50,7 +137,7 @@ func displayConfiguration() {
 	fmt.Println("Current Configuration:")
 	fmt.Println("----------------------")
 
-	for _, p := range []string{ProviderOpenAI, ProviderAnthropic, ProviderOllama} {
+	for _, p := range provider.Names() {
 		key, err := config.LoadAPIKey(p)
 		if err != nil && err != config.ErrNoConfigFile && err != config.ErrProviderNotFound {
 			fmt.Printf("%s: Error loading configuration: %v\n", p, err)
@@ -65,4 +152,59 @@ func displayConfiguration() {
 			fmt.Printf("%s: %s\n", p, maskedKey)
 		}
 	}
+
+	settings, err := resolveSettings(nil)
+	if err != nil {
+		fmt.Printf("\nError loading settings: %v\n", err)
+		return
+	}
+
+	fmt.Println()
+	fmt.Println("Settings:")
+	fmt.Println("---------")
+	printSettings(settings)
+}
+
+// printSettings prints every setting with its effective value and where it came from
+func printSettings(settings *config.Resolved) {
+	width := 0
+	for _, k := range config.Keys() {
+		value, _ := settings.Get(k.Name)
+		if n := len(k.Name) + len(value); n > width {
+			width = n
+		}
+	}
+
+	for _, k := range config.Keys() {
+		value, _ := settings.Get(k.Name)
+		padding := strings.Repeat(" ", width-len(k.Name)-len(value))
+		fmt.Printf("%s = %s%s  # %s\n", k.Name, value, padding, settings.Origin(k.Name))
+	}
+}
+
+// saveDefaultFormat validates and stores the default commit message format
+func saveDefaultFormat(format string) error {
+	registry, err := loadPrompts()
+	if err != nil {
+		return err
+	}
+	if err := registry.Validate(format); err != nil {
+		return err
+	}
+
+	return config.SetUserSetting("format", format)
+}
+
+// resolveSettings resolves the effective settings, warning about settings the
+// repository settings file isn't allowed to set
+func resolveSettings(flags map[string]string) (*config.Resolved, error) {
+	settings, err := config.Resolve(flags)
+	if err != nil {
+		return nil, err
+	}
+	if len(settings.Ignored) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring %s in %s, repository settings may only set the format, language, scopes, exclude, context.*, lint.* and ticket.* settings\n",
+			strings.Join(settings.Ignored, ", "), settings.IgnoredPath)
+	}
+	return settings, nil
 }
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/hamzabow/co/internal/config"
+	"github.com/hamzabow/co/internal/editor"
+	"github.com/hamzabow/co/internal/git"
+	"github.com/hamzabow/co/internal/lint"
+	"github.com/hamzabow/co/internal/prompts"
+	"github.com/spf13/cobra"
+)
+
+// ErrLintFailed is returned when a linted message breaks an error level rule
+var ErrLintFailed = errors.New("commit message lint failed")
+
+var (
+	lintFile  string
+	lintRange string
+)
+
+// lintCmd checks commit messages against the configured lint rules
+var lintCmd = &cobra.Command{
+	Use:   "lint [message]",
+	Short: "Check commit messages against the lint rules",
+	Long: `Check commit messages against the lint rules configured in the lint.*
+settings. The message is taken from the arguments, from a file with --file (for
+example in a commit-msg hook), from the commits of a revision range with
+--range, or from standard input.
+
+Exits with a non-zero status when a message breaks an error level rule;
+warnings are only reported.`,
+	Example: `  co lint "feat(api): add login"
+  co lint --file .git/COMMIT_EDITMSG
+  co lint --range main..HEAD`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		settings, err := resolveSettings(nil)
+		if err != nil {
+			return fmt.Errorf("failed to load settings: %v", err)
+		}
+		cfg := lintConfig(settings)
+
+		if lintRange != "" {
+			commits, err := git.CommitsInRange(lintRange)
+			if err != nil {
+				return err
+			}
+
+			failed := false
+			for _, commit := range commits {
+				violations := lint.Lint(commit.Message, cfg)
+				if len(violations) == 0 {
+					continue
+				}
+				subject, _, _ := strings.Cut(commit.Message, "\n")
+				fmt.Printf("%s %s\n", commit.Hash, subject)
+				printViolations(violations)
+				failed = failed || lint.HasErrors(violations)
+			}
+			if failed {
+				return ErrLintFailed
+			}
+			return nil
+		}
+
+		message, err := lintInput(args)
+		if err != nil {
+			return err
+		}
+
+		violations := lint.Lint(message, cfg)
+		printViolations(violations)
+		if lint.HasErrors(violations) {
+			return ErrLintFailed
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+
+	lintCmd.Flags().StringVarP(&lintFile, "file", "F", "", "Read the message from a file, \"-\" for standard input")
+	lintCmd.Flags().StringVar(&lintRange, "range", "", "Lint the commits of a revision range, e.g. main..HEAD")
+	lintCmd.MarkFlagsMutuallyExclusive("file", "range")
+}
+
+// lintInput reads the message to lint from the arguments, --file or standard input
+func lintInput(args []string) (string, error) {
+	if len(args) > 0 {
+		if lintFile != "" {
+			return "", errors.New("pass the message either as arguments or with --file")
+		}
+		return strings.Join(args, " "), nil
+	}
+
+	var data []byte
+	var err error
+	if lintFile != "" && lintFile != "-" {
+		data, err = os.ReadFile(lintFile)
+	} else {
+		data, err = io.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		return "", err
+	}
+	// The lines git ignores are removed, including everything below the
+	// scissors line of git commit --verbose
+	return editor.Cleanup(string(data), git.CommentChar()), nil
+}
+
+// printViolations prints one violation per line
+func printViolations(violations []lint.Violation) {
+	for _, v := range violations {
+		fmt.Printf("  %s: line %s\n", v.Severity, v)
+	}
+}
+
+// lintConfig builds the lint rules from the settings. The header format is
+// only enforced for formats meant to produce Conventional Commits, which
+// includes custom templates unless lint.conventional is turned off.
+func lintConfig(settings *config.Resolved) lint.Config {
+	conventional := settings.Lint.Conventional
+	switch settings.Format {
+	case prompts.FormatGitmoji, prompts.FormatGitmojiShortcode, prompts.FormatSimple:
+		conventional = false
+	}
+
+	return lint.Config{
+		Conventional:      conventional,
+		Types:             settings.Lint.Types,
+		Scopes:            settings.Scopes,
+		RequireScope:      settings.Lint.RequireScope,
+		SubjectMaxLength:  settings.Lint.SubjectMaxLength,
+		BodyMaxLineLength: settings.Lint.BodyMaxLineLength,
+		Imperative:        settings.Lint.Imperative,
+		RequiredFooters:   settings.Lint.RequiredFooters,
+		Disabled:          settings.Lint.Disable,
+	}
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hamzabow/co/internal/provider"
+	"github.com/spf13/cobra"
+)
+
+var modelsProvider string
+
+// modelsCmd represents the models command
+var modelsCmd = &cobra.Command{
+	Use:   "models",
+	Short: "List the models available from the AI provider",
+	Long: `List the models available from the active AI provider.
+The model currently used for generation is marked with an asterisk.
+Use 'co config set model <name>' or the --model flag to change it.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		settings, err := resolveSettings(nil)
+		if err != nil {
+			return fmt.Errorf("failed to load settings: %v", err)
+		}
+
+		// The configured model, endpoint and headers belong to the configured
+		// provider, another one is listed with its defaults
+		if cmd.Flags().Changed("provider") && modelsProvider != settings.Provider {
+			for name, value := range map[string]string{"provider": modelsProvider, "model": "", "base_url": "", "headers": ""} {
+				if err := settings.Set(name, value); err != nil {
+					return fmt.Errorf("failed to load settings: %v", err)
+				}
+			}
+		}
+
+		p, err := newProvider(settings)
+		if err != nil {
+			return err
+		}
+
+		models, err := p.ListModels(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("failed to list %s models: %v", provider.DisplayName(p.Name()), err)
+		}
+		sort.Strings(models)
+
+		fmt.Printf("%s models:\n", provider.DisplayName(p.Name()))
+		current := false
+		for _, model := range models {
+			if !current && provider.SameModel(p.Name(), model, p.Model()) {
+				current = true
+				fmt.Printf("* %s\n", model)
+			} else {
+				fmt.Printf("  %s\n", model)
+			}
+		}
+
+		if !current {
+			fmt.Printf("\nCurrent model %s is not in the list\n", p.Model())
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(modelsCmd)
+
+	modelsCmd.Flags().StringVarP(&modelsProvider, "provider", "p", ProviderOpenAI, "AI provider to list models for (openai, anthropic, ollama)")
+}
@@ -1,23 +1,39 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/hamzabow/co/internal/apikeyinput"
+	"github.com/hamzabow/co/internal/candidatelist"
 	"github.com/hamzabow/co/internal/config"
+	"github.com/hamzabow/co/internal/editor"
 	"github.com/hamzabow/co/internal/genmessage"
+	"github.com/hamzabow/co/internal/git"
+	"github.com/hamzabow/co/internal/gitdiff"
+	"github.com/hamzabow/co/internal/lint"
 	"github.com/hamzabow/co/internal/messagetextarea"
+	"github.com/hamzabow/co/internal/prompts"
+	"github.com/hamzabow/co/internal/provider"
+	"github.com/joho/godotenv"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 // Provider constants for API keys
 const (
-	ProviderOpenAI    = "openai"
-	ProviderAnthropic = "anthropic"
-	ProviderOllama    = "ollama"
+	ProviderOpenAI    = provider.OpenAI
+	ProviderAnthropic = provider.Anthropic
+	ProviderOllama    = provider.Ollama
 )
 
 // Define error style
@@ -31,33 +47,129 @@ var errorStyle = lipgloss.NewStyle().
 var (
 	// Used for flags
 	providerName string
+	modelName    string
+	baseURL      string
+	headers      []string
+	timeout      time.Duration
+	formatName   string
+	languageName string
+	summarize    string
+	candidates   int
 	skipPrompt   bool
+	useEditor    bool
 
 	// rootCmd represents the base command when called without any subcommands
 	rootCmd = &cobra.Command{
-		Use:   "co",
+		Use:   "co [flags] [-- git commit flags]",
 		Short: "Generate AI-powered Git commit messages",
 		Long: `Co is a CLI tool that generates Git commit messages using AI.
 It analyzes your staged changes and suggests a meaningful commit message.`,
+		// Errors are printed by Execute; usage is only useful for flag errors
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          commitArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runRootCommand()
+			return runRootCommand(cmd, args)
 		},
 	}
 )
 
+// ErrCommitFailed is returned when git commit exits with an error
+var ErrCommitFailed = errors.New("git commit failed")
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	// Cancel in-flight requests on interrupt
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	loadDotEnv()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
+		if errors.Is(err, genmessage.ErrGenerationCancelled) {
+			fmt.Println("Generation cancelled")
+			// Conventional exit status for a process interrupted by the user
+			os.Exit(130)
+		}
 		fmt.Println(err)
+
+		// git has explained what went wrong, its exit status is passed on
+		var exitErr *exec.ExitError
+		if errors.Is(err, ErrCommitFailed) && errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+			os.Exit(exitErr.ExitCode())
+		}
 		os.Exit(1)
 	}
 }
 
+// loadDotEnv sets the provider API key variables found in a .env file in the
+// current directory, unless they are already set. Nothing else is read from it:
+// a .env file can come with a cloned repository, which must not be able to send
+// requests elsewhere through CO_* settings or OLLAMA_HOST
+func loadDotEnv() {
+	vars, err := godotenv.Read()
+	if err != nil {
+		return
+	}
+	for _, name := range provider.Names() {
+		env := provider.APIKeyEnv(name)
+		value, ok := vars[env]
+		if env == "" || !ok {
+			continue
+		}
+		if _, set := os.LookupEnv(env); !set {
+			os.Setenv(env, value)
+		}
+	}
+}
+
 func init() {
 	// Here you will define your flags and configuration settings.
 	rootCmd.Flags().StringVarP(&providerName, "provider", "p", ProviderOpenAI, "AI provider to use (openai, anthropic, ollama)")
+	rootCmd.Flags().StringVarP(&modelName, "model", "m", "", "Model to use, empty uses the provider default (see 'co models')")
+	rootCmd.Flags().StringVar(&baseURL, "base-url", "", "API endpoint, e.g. an OpenAI-compatible gateway")
+	rootCmd.Flags().StringArrayVar(&headers, "header", nil, "Extra HTTP header sent with every request, as \"Name: Value\" (repeatable)")
+	rootCmd.Flags().DurationVar(&timeout, "timeout", config.DefaultSettings().Timeout, "Maximum time to wait for the AI provider, e.g. 90s or 5m (0 disables it)")
+	rootCmd.Flags().StringVarP(&formatName, "format", "f", prompts.DefaultFormat, "Commit message format ("+strings.Join(prompts.Formats(), ", ")+", or a custom template name)")
+	rootCmd.Flags().StringVar(&languageName, "language", "", "Language to write the commit message in (e.g. English, French)")
+	rootCmd.Flags().StringVar(&summarize, "summarize", config.SummarizeNever, "Summarize diffs too large for the model in parts (never, auto, always)")
+	rootCmd.Flags().IntVarP(&candidates, "candidates", "n", 1, "Number of alternative messages to generate and pick from (ignored with --yes)")
 	rootCmd.Flags().BoolVarP(&skipPrompt, "yes", "y", false, "Skip the confirmation prompt and automatically commit")
+	rootCmd.Flags().BoolVar(&useEditor, "editor", false, "Edit the message in the editor git uses instead of the built-in one")
+}
+
+// settingFlags maps root command flags to the settings they override
+var settingFlags = map[string]string{
+	"provider":   "provider",
+	"model":      "model",
+	"base-url":   "base_url",
+	"header":     "headers",
+	"timeout":    "timeout",
+	"format":     "format",
+	"language":   "language",
+	"summarize":  "summarize",
+	"candidates": "candidates",
+	"yes":        "ui.skip_editor",
+	"editor":     "ui.external_editor",
+}
+
+// flagOverrides collects the settings explicitly set on the command line
+func flagOverrides(cmd *cobra.Command) map[string]string {
+	overrides := make(map[string]string)
+	for flag, key := range settingFlags {
+		f := cmd.Flags().Lookup(flag)
+		if f == nil || !f.Changed {
+			continue
+		}
+		// Repeatable flags are passed one item per line so items may contain commas
+		if list, ok := f.Value.(pflag.SliceValue); ok {
+			overrides[key] = strings.Join(list.GetSlice(), "\n")
+		} else {
+			overrides[key] = f.Value.String()
+		}
+	}
+	return overrides
 }
 
 func displayError(format string, v ...interface{}) {
@@ -66,50 +178,177 @@ func displayError(format string, v ...interface{}) {
 	os.Exit(1)
 }
 
-func runRootCommand() error {
-	// Initialize API key variable
-	var key string
-	var err error
-
-	// Try to load API key from config file
-	key, err = config.LoadAPIKey(providerName)
-	if err != nil && err != config.ErrNoConfigFile && err != config.ErrProviderNotFound {
-		displayError("Failed to load API key from config: %v", err)
+// providerErrorHint suggests how to fix a failed provider request, or returns
+// an empty string when the error isn't a provider error
+func providerErrorHint(err error, settings *config.Resolved) string {
+	var perr *provider.Error
+	if !errors.As(err, &perr) {
+		return ""
 	}
+	name := provider.DisplayName(perr.Provider)
 
-	// If no key found, prompt the user
-	if key == "" {
-		key, err = apikeyinput.PromptApiKeyWithRetries()
-		if err != nil {
-			if err == apikeyinput.ErrEmptyApiKey {
-				fmt.Println("No API key provided. Exiting.")
-				return fmt.Errorf("no API key provided")
-			} else {
-				return fmt.Errorf("%v", err)
-			}
+	switch {
+	case errors.Is(err, provider.ErrAuth):
+		if env := provider.APIKeyEnv(perr.Provider); env != "" {
+			return fmt.Sprintf("The %s API key was rejected. Update it with 'co config --provider %s --key <key>' or the %s environment variable.", name, perr.Provider, env)
 		}
+		return fmt.Sprintf("%s rejected the request credentials. Check the headers setting.", name)
+	case errors.Is(err, provider.ErrQuotaExceeded):
+		return fmt.Sprintf("Your %s account has run out of quota or credits. Check your plan and billing details.", name)
+	case errors.Is(err, provider.ErrRateLimited):
+		return fmt.Sprintf("%s is rate limiting requests, even after %d retries. Wait a moment and try again, or allow more retries with 'co config set retries 5'.", name, settings.Retries)
+	case errors.Is(err, provider.ErrContextLength):
+		return "The staged changes are too large for the model's context window. Stage fewer changes, set context_window to the model's actual context window, summarize the diff in parts with --summarize auto, or choose a model with a larger context window with --model."
+	case errors.Is(err, provider.ErrModelNotFound):
+		return fmt.Sprintf("The model is not available from %s. Run 'co models' to see the available models.", name)
+	case errors.Is(err, provider.ErrNetwork):
+		return fmt.Sprintf("Could not reach %s. Check your network connection and the base_url setting.", name)
+	case errors.Is(err, provider.ErrServer):
+		return fmt.Sprintf("%s is having trouble right now. Try again later.", name)
+	default:
+		return ""
+	}
+}
 
-		// Save the key to config for future use
-		if key != "" {
-			if err := config.SaveAPIKey(providerName, key); err != nil {
-				fmt.Printf("Warning: Failed to save API key to config: %v\n", err)
-			}
-		}
+// commitArgs only accepts arguments after "--", which are passed to git commit
+func commitArgs(cmd *cobra.Command, args []string) error {
+	if dash := cmd.ArgsLenAtDash(); len(args) > 0 && dash != 0 {
+		return fmt.Errorf("unknown argument %q, flags for git commit go after --, e.g. co -- --signoff", args[0])
+	}
+	return nil
+}
+
+func runRootCommand(cmd *cobra.Command, gitArgs []string) error {
+	if candidates < 1 {
+		return fmt.Errorf("invalid argument \"%d\" for \"-n, --candidates\" flag: must be at least 1", candidates)
 	}
 
-	// Generate commit message using the AI provider
-	response, err := genmessage.GenerateCommitMessage(key)
+	settings, err := resolveSettings(flagOverrides(cmd))
 	if err != nil {
-		return fmt.Errorf("failed to generate commit message: %v", err)
+		return fmt.Errorf("failed to load settings: %v", err)
 	}
 
-	if skipPrompt {
-		// Skip message editing and directly commit
-		return commit(response)
+	p, err := newProvider(settings)
+	if err != nil {
+		return err
 	}
 
+	registry, err := loadPrompts()
+	if err != nil {
+		return err
+	}
+
+	// Validate early so a typo doesn't cost an API call
+	if err := registry.Validate(settings.Format); err != nil {
+		return err
+	}
+
+	exclude, err := excludeMatcher(settings)
+	if err != nil {
+		return err
+	}
+
+	lintRules := lintConfig(settings)
+
+	// Prepare the prompt from the staged changes
+	gen, err := genmessage.NewGenerator(cmd.Context(), genmessage.Options{
+		Provider:         p,
+		Prompts:          registry,
+		Format:           settings.Format,
+		Language:         settings.Language,
+		Scopes:           settings.Scopes,
+		MaxDiffSize:      settings.MaxDiffSize,
+		ContextWindow:    settings.ContextWindow,
+		Summarize:        settings.Summarize,
+		SummarizeBy:      settings.SummarizeBy,
+		SummarizeWorkers: settings.SummarizeWorkers,
+		Exclude:          exclude,
+		ExcludeBinary:    settings.DefaultExcludes,
+		Secrets:          settings.Secrets,
+		KnownSecrets:     storedAPIKeys(),
+		Context: genmessage.ContextOptions{
+			Branch:        settings.Context.Branch,
+			Stat:          settings.Context.Stat,
+			Files:         settings.Context.Files,
+			RecentCommits: settings.Context.RecentCommits,
+			Examples:      settings.Context.Examples,
+		},
+		Ticket: genmessage.TicketOptions{
+			Pattern:   settings.Ticket.Pattern,
+			Placement: settings.Ticket.Placement,
+			Template:  settings.Ticket.Template,
+		},
+		Lint:        &lintRules,
+		MaxAttempts: settings.Lint.MaxAttempts,
+		AutoStage:   settings.UI.AutoStage,
+		Stream:      settings.Stream,
+		Timeout:     settings.Timeout,
+	})
+	if err != nil {
+		return generationError(err, settings)
+	}
+
+	// Generate commit messages using the AI provider. Without the editor there
+	// is no one to pick among candidates, so only one is requested.
+	n := settings.Candidates
+	if settings.UI.SkipEditor {
+		n = 1
+	}
+	messages, err := gen.Generate(cmd.Context(), n)
+	if err != nil {
+		return generationError(err, settings)
+	}
+	response := messages[0]
+
+	if settings.UI.SkipEditor {
+		// Skip message editing and directly commit, reporting broken rules on the way
+		if violations := lint.Lint(response, lintRules); len(violations) > 0 {
+			fmt.Println("The generated message breaks lint rules:")
+			printViolations(violations)
+		}
+		return commit(response, gitArgs)
+	}
+
+	if settings.Candidates > 1 {
+		picked, ok, err := pickCandidate(cmd.Context(), gen, messages, settings.Candidates, &lintRules)
+		if err != nil {
+			return generationError(err, settings)
+		}
+		if !ok {
+			fmt.Println("Commit cancelled")
+			return nil
+		}
+		response = picked
+	}
+
+	if settings.UI.ExternalEditor {
+		edited, err := editor.Edit(response)
+		if err != nil {
+			return err
+		}
+		if edited == "" {
+			fmt.Println("Aborting commit due to empty commit message")
+			return nil
+		}
+		if violations := lint.Lint(edited, lintRules); len(violations) > 0 {
+			fmt.Println("The message breaks lint rules:")
+			printViolations(violations)
+		}
+		return commit(edited, gitArgs)
+	}
+
+	// The diff is only shown for reference, so failing to get it isn't fatal
+	stagedDiff, _ := git.StagedDiff()
+
 	// Show text area for editing the message
-	commitMessage, commitResult := messagetextarea.MessageTextArea(response)
+	commitMessage, commitResult := messagetextarea.MessageTextArea(response, messagetextarea.Options{
+		Lint:         &lintRules,
+		Regenerate:   gen.Regenerate,
+		Refine:       gen.Refine,
+		Diff:         stagedDiff,
+		SubjectLimit: settings.UI.SubjectLimit,
+		WrapWidth:    settings.UI.WrapWidth,
+	})
 
 	if commitMessage == "" {
 		fmt.Println("No commit message provided")
@@ -117,18 +356,212 @@ func runRootCommand() error {
 	}
 
 	if commitResult == messagetextarea.ResultCommit {
-		return commit(commitMessage)
+		return commit(commitMessage, gitArgs)
 	}
 
 	fmt.Println("Commit cancelled")
 	return nil
 }
 
-func commit(msg string) error {
-	cmd := exec.Command("git", "commit", "-m", msg)
-	err := cmd.Run()
+// generationError adds a hint on how to fix the error when there is one, and
+// exits; other errors are returned to be printed by Execute
+func generationError(err error, settings *config.Resolved) error {
+	if hint := providerErrorHint(err, settings); hint != "" {
+		displayError("%v\n\n%s", err, hint)
+	}
+	if errors.Is(err, genmessage.ErrSecretsDetected) {
+		displayError("%v\n\nRemove them from the staged changes, or set secrets to redact to send the diff with the secrets replaced.", err)
+	}
+	return fmt.Errorf("failed to generate commit message: %w", err)
+}
+
+// pickCandidate lets the user choose among the candidates, generating n more
+// each time they ask for them. It returns false when the user cancelled.
+func pickCandidate(ctx context.Context, gen *genmessage.Generator, candidates []string, n int, lintRules *lint.Config) (string, bool, error) {
+	selected := 0
+	for {
+		index, result, err := candidatelist.Pick(candidates, selected, lintRules)
+		if err != nil {
+			return "", false, err
+		}
+
+		switch result {
+		case candidatelist.ResultPick:
+			return candidates[index], true, nil
+		case candidatelist.ResultMore:
+			more, err := gen.Generate(ctx, n)
+			if err != nil {
+				return "", false, err
+			}
+			// Select the first new candidate
+			selected = len(candidates)
+			for _, candidate := range more {
+				if !slices.Contains(candidates, candidate) {
+					candidates = append(candidates, candidate)
+				}
+			}
+		default:
+			return "", false, nil
+		}
+	}
+}
+
+// newProvider creates the configured AI provider, resolving its API key first
+func newProvider(settings *config.Resolved) (provider.Provider, error) {
+	key, err := resolveAPIKey(settings.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	headers, err := provider.ParseHeaders(settings.Headers)
+	if err != nil {
+		return nil, err
+	}
+
+	retry := provider.DefaultRetryPolicy
+	retry.MaxRetries = settings.Retries
+	retry.InitialBackoff = settings.RetryBackoff
+
+	return provider.New(settings.Provider, provider.Options{
+		Retry:           &retry,
+		APIKey:          key,
+		Model:           settings.Model,
+		BaseURL:         settings.BaseURL,
+		Temperature:     settings.Temperature,
+		Headers:         headers,
+		AzureDeployment: settings.Azure.Deployment,
+		AzureAPIVersion: settings.Azure.APIVersion,
+	})
+}
+
+// loadPrompts builds the prompt registry from the built-in formats, custom
+// templates in the user's config dir, and templates in the repository's
+// .co/prompts directory (which take precedence)
+func loadPrompts() (*prompts.Registry, error) {
+	registry := prompts.NewRegistry()
+
+	configDir, err := config.GetConfigDir()
 	if err != nil {
-		return fmt.Errorf("failed to commit: %v", err)
+		return nil, err
+	}
+	if err := registry.LoadDir(filepath.Join(configDir, "prompts")); err != nil {
+		return nil, err
+	}
+
+	// Outside of a git repository there are no repo-local templates
+	if topLevel, err := git.TopLevel(); err == nil {
+		if err := registry.LoadDir(filepath.Join(topLevel, ".co", "prompts")); err != nil {
+			return nil, err
+		}
+	}
+
+	return registry, nil
+}
+
+// excludeMatcher builds the patterns of files left out of the diff: the
+// defaults, then the exclude setting, then the repository's .coignore, so that
+// later "!pattern" lines can re-include files excluded earlier
+func excludeMatcher(settings *config.Resolved) (*gitdiff.Matcher, error) {
+	var patterns []string
+	if settings.DefaultExcludes {
+		patterns = append(patterns, gitdiff.DefaultExcludes...)
+	}
+	patterns = append(patterns, settings.Exclude...)
+
+	if topLevel, err := git.TopLevel(); err == nil {
+		ignored, err := gitdiff.ReadIgnoreFile(filepath.Join(topLevel, gitdiff.IgnoreFileName))
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, ignored...)
+	}
+
+	return gitdiff.NewMatcher(patterns)
+}
+
+// storedAPIKeys returns the API keys of every provider found in the config
+// file or the environment, so that they are never sent as part of a diff
+func storedAPIKeys() []string {
+	var keys []string
+	for _, name := range provider.Names() {
+		if key, err := config.LoadAPIKey(name); err == nil && key != "" {
+			keys = append(keys, key)
+		}
+		if env := provider.APIKeyEnv(name); env != "" {
+			if key := os.Getenv(env); key != "" {
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
+// resolveAPIKey finds the API key for the provider, looking at the config file,
+// then the provider's environment variable, and finally prompting the user
+func resolveAPIKey(name string) (string, error) {
+	// Providers such as Ollama run locally and don't need a key
+	if !provider.RequiresAPIKey(name) {
+		return "", nil
+	}
+
+	// Try to load API key from config file
+	key, err := config.LoadAPIKey(name)
+	if err != nil && err != config.ErrNoConfigFile && err != config.ErrProviderNotFound {
+		displayError("Failed to load API key from config: %v", err)
+	}
+	if key != "" {
+		return key, nil
+	}
+
+	// Fall back to the conventional environment variable (e.g. OPENAI_API_KEY)
+	if env := provider.APIKeyEnv(name); env != "" {
+		if key = os.Getenv(env); key != "" {
+			return key, nil
+		}
+	}
+
+	// If no key found, prompt the user
+	key, err = apikeyinput.PromptApiKeyWithRetries(provider.DisplayName(name))
+	if err != nil {
+		if err == apikeyinput.ErrEmptyApiKey {
+			fmt.Println("No API key provided. Exiting.")
+			return "", fmt.Errorf("no API key provided")
+		}
+		return "", fmt.Errorf("%v", err)
+	}
+
+	// Save the key to config for future use
+	if key != "" {
+		if err := config.SaveAPIKey(name, key); err != nil {
+			fmt.Printf("Warning: Failed to save API key to config: %v\n", err)
+		}
+	}
+
+	return key, nil
+}
+
+// commit commits the staged changes with msg, passing gitArgs on to git
+// commit. The message is passed in a file so that it reaches git as written,
+// and git uses the terminal for its output, hooks and prompts such as GPG passphrases.
+func commit(msg string, gitArgs []string) error {
+	file, err := os.CreateTemp("", "co-message-*.txt")
+	if err != nil {
+		return fmt.Errorf("failed to write the commit message: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.WriteString(msg)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write the commit message: %w", err)
+	}
+
+	cmd := exec.Command("git", append([]string{"commit", "-F", file.Name()}, gitArgs...)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w: %w", ErrCommitFailed, err)
 	}
 	fmt.Println("Commit successful")
 	return nil
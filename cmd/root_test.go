@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// parseRoot parses args with the root command's flags and arguments check,
+// returning the arguments passed to git commit and the settings overridden
+func parseRoot(t *testing.T, args []string) ([]string, map[string]string, error) {
+	t.Helper()
+
+	var gitArgs []string
+	var overrides map[string]string
+	cmd := &cobra.Command{
+		Use:           "co",
+		Args:          commitArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			gitArgs, overrides = args, flagOverrides(cmd)
+		},
+	}
+
+	// The flags are copied so that each parse starts unchanged, from the defaults
+	rootCmd.Flags().VisitAll(func(f *pflag.Flag) {
+		flag := *f
+		if list, ok := flag.Value.(pflag.SliceValue); ok {
+			list.Replace(nil)
+		} else if err := flag.Value.Set(flag.DefValue); err != nil {
+			t.Fatal(err)
+		}
+		cmd.Flags().AddFlag(&flag)
+	})
+
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return gitArgs, overrides, err
+}
+
+func TestCommitArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+		err  string
+	}{
+		{"none", nil, nil, ""},
+		{"after dash", []string{"--", "--signoff", "-S"}, []string{"--signoff", "-S"}, ""},
+		{"flags before dash", []string{"-y", "--", "--no-verify"}, []string{"--no-verify"}, ""},
+		{"values after dash", []string{"--", "--author", "A <a@b.c>", "--trailer", "Refs: X-1"}, []string{"--author", "A <a@b.c>", "--trailer", "Refs: X-1"}, ""},
+		{"co flags after dash", []string{"--", "-m", "x", "--yes"}, []string{"-m", "x", "--yes"}, ""},
+		{"empty after dash", []string{"--"}, nil, ""},
+		{"argument before dash", []string{"signoff", "--", "-S"}, nil, `unknown argument "signoff"`},
+		{"argument without dash", []string{"fix"}, nil, `unknown argument "fix"`},
+		{"git flag without dash", []string{"--signoff"}, nil, "unknown flag: --signoff"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := parseRoot(t, tt.args)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("err = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("git args = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlagOverrides(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want map[string]string
+	}{
+		{"none", nil, map[string]string{}},
+		{"defaults given explicitly", []string{"--format", "conventional", "-n", "1"}, map[string]string{"format": "conventional", "candidates": "1"}},
+		{"short flags", []string{"-p", "ollama", "-m", "llama3.2", "-y"}, map[string]string{"provider": "ollama", "model": "llama3.2", "ui.skip_editor": "true"}},
+		{"values", []string{"--base-url", "http://localhost:1234/v1", "--timeout", "90s", "--language", "French"},
+			map[string]string{"base_url": "http://localhost:1234/v1", "timeout": "1m30s", "language": "French"}},
+		{"header with commas", []string{"--header", "X-Foo: a, b"}, map[string]string{"headers": "X-Foo: a, b"}},
+		{"repeated headers", []string{"--header", "X-Foo: a, b", "--header", "X-Bar: c"}, map[string]string{"headers": "X-Foo: a, b\nX-Bar: c"}},
+		{"editor", []string{"--editor", "--summarize", "auto"}, map[string]string{"ui.external_editor": "true", "summarize": "auto"}},
+		{"git args ignored", []string{"--", "--signoff"}, map[string]string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, got, err := parseRoot(t, tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("flagOverrides() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -47,8 +47,9 @@ var (
 			MarginTop(1)
 )
 
-func PromptApiKeyWithRetries() (string, error) {
-	m := initialModel()
+// PromptApiKeyWithRetries asks for the API key of the named provider (e.g. "OpenAI")
+func PromptApiKeyWithRetries(providerName string) (string, error) {
+	m := initialModel(providerName)
 	p := tea.NewProgram(m)
 	finalModel, err := p.Run()
 	if err != nil {
@@ -68,8 +69,8 @@ func PromptApiKeyWithRetries() (string, error) {
 	return m.textInput.Value(), nil
 }
 
-func PromptApiKey() (string, bool) {
-	p := tea.NewProgram(initialModel())
+func PromptApiKey(providerName string) (string, bool) {
+	p := tea.NewProgram(initialModel(providerName))
 	m, err := p.Run()
 	if err != nil {
 		log.Fatal(err)
@@ -84,17 +85,18 @@ func PromptApiKey() (string, bool) {
 type errMsg error
 
 type model struct {
-	textInput   textinput.Model
-	err         error
-	userQuit    bool
-	attempts    int
-	maxAttempts int
-	showError   bool
-	width       int
-	height      int
+	providerName string
+	textInput    textinput.Model
+	err          error
+	userQuit     bool
+	attempts     int
+	maxAttempts  int
+	showError    bool
+	width        int
+	height       int
 }
 
-func initialModel() model {
+func initialModel(providerName string) model {
 	ti := textinput.New()
 	ti.Placeholder = "sk-..."
 	ti.Focus()
@@ -102,14 +104,15 @@ func initialModel() model {
 	ti.EchoCharacter = '•'
 
 	return model{
-		textInput:   ti,
-		err:         nil,
-		userQuit:    false,
-		attempts:    0,
-		maxAttempts: 3,
-		showError:   false,
-		width:       80,
-		height:      24,
+		providerName: providerName,
+		textInput:    ti,
+		err:          nil,
+		userQuit:     false,
+		attempts:     0,
+		maxAttempts:  3,
+		showError:    false,
+		width:        80,
+		height:       24,
 	}
 }
 
@@ -184,7 +187,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m model) View() string {
 	var view strings.Builder
 
-	view.WriteString(titleStyle.Render(" OpenAI API Key "))
+	view.WriteString(titleStyle.Render(" " + m.providerName + " API Key "))
 	view.WriteString("\n\n")
 
 	dynamicInputBoxStyle := inputBoxStyle.Width(m.width - 4)
@@ -0,0 +1,189 @@
+package candidatelist
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hamzabow/co/internal/lint"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Result represents what the user chose to do with the candidates
+type Result int
+
+const (
+	// ResultCancel means the user quit without picking a candidate
+	ResultCancel Result = iota
+	// ResultPick means the user picked the selected candidate
+	ResultPick
+	// ResultMore means the user asked for more candidates
+	ResultMore
+)
+
+// Model is a list of candidate commit messages with a preview of the selected one
+type Model struct {
+	candidates []string
+	lint       *lint.Config
+	cursor     int
+	result     Result
+	width      int
+	height     int
+}
+
+var (
+	// Title style with white text on purple background
+	titleStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#FAFAFA")).
+			Background(lipgloss.Color("#7D56F4")).
+			PaddingLeft(2).
+			PaddingRight(2).
+			MarginBottom(1).
+			MarginTop(1)
+
+	// Container style for the entire view
+	containerStyle = lipgloss.NewStyle().
+			MarginLeft(1)
+
+	// Active item style
+	activeItemStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FAFAFA")).
+			Background(lipgloss.Color("#7D56F4")).
+			Bold(true).
+			PaddingLeft(1).
+			PaddingRight(1)
+
+	// Inactive item style
+	inactiveItemStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#AAAAAA")).
+				PaddingLeft(1).
+				PaddingRight(1)
+
+	// Lint error marker style
+	lintErrorStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FF5555"))
+
+	// Preview box for the selected message
+	previewStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("#7D56F4")).
+			Foreground(lipgloss.Color("#FAFAFA")).
+			Padding(0, 1).
+			MarginTop(1)
+
+	// Help text style
+	helpStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#626262")).
+			MarginTop(1)
+)
+
+// New creates a list of the candidates. When lintConfig is set, candidates
+// breaking error level rules are marked.
+func New(candidates []string, lintConfig *lint.Config) Model {
+	return Model{
+		candidates: candidates,
+		lint:       lintConfig,
+		result:     ResultCancel,
+		width:      80, // Default value, will be updated
+		height:     24, // Default value, will be updated
+	}
+}
+
+// Pick shows the candidates, starting with the one at index selected, and
+// returns the index of the chosen candidate along with what the user chose
+func Pick(candidates []string, selected int, lintConfig *lint.Config) (int, Result, error) {
+	m := New(candidates, lintConfig)
+	m.cursor = min(max(selected, 0), len(candidates)-1)
+
+	finalModel, err := tea.NewProgram(m).Run()
+	if err != nil {
+		return 0, ResultCancel, err
+	}
+
+	m = finalModel.(Model)
+	return m.cursor, m.result, nil
+}
+
+// Init initializes the model
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles key presses and window resizes
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k", "shift+tab":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j", "tab":
+			if m.cursor < len(m.candidates)-1 {
+				m.cursor++
+			}
+		case "enter":
+			m.result = ResultPick
+			return m, tea.Quit
+		case "m", "r":
+			m.result = ResultMore
+			return m, tea.Quit
+		case "esc", "ctrl+c", "q":
+			m.result = ResultCancel
+			return m, tea.Quit
+		default:
+			// Number keys select a candidate directly
+			if key := msg.String(); len(key) == 1 && key >= "1" && key <= "9" {
+				if n := int(key[0] - '1'); n < len(m.candidates) {
+					m.cursor = n
+				}
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// View renders the list and the preview of the selected candidate
+func (m Model) View() string {
+	var view strings.Builder
+
+	view.WriteString(titleStyle.Render(" Pick a Commit Message "))
+	view.WriteString("\n")
+
+	// Subjects are cut to the width of the terminal
+	width := max(m.width-10, 20)
+	for i, candidate := range m.candidates {
+		subject, _, _ := strings.Cut(candidate, "\n")
+		if len([]rune(subject)) > width {
+			subject = string([]rune(subject)[:width-1]) + "…"
+		}
+
+		style := inactiveItemStyle
+		if i == m.cursor {
+			style = activeItemStyle
+		}
+
+		marker := " "
+		if m.lint != nil && lint.HasErrors(lint.Lint(candidate, *m.lint)) {
+			marker = lintErrorStyle.Render("✗")
+		}
+		view.WriteString(fmt.Sprintf("%s %s\n", marker, style.Render(fmt.Sprintf("%d. %s", i+1, subject))))
+	}
+
+	if len(m.candidates) > 0 {
+		view.WriteString(previewStyle.Width(max(m.width-6, 20)).Render(m.candidates[m.cursor]))
+		view.WriteString("\n")
+	}
+
+	view.WriteString(helpStyle.Render("↑/↓ to select, Enter to edit the selected message, m for more, Esc to cancel"))
+
+	return containerStyle.Render(view.String())
+}
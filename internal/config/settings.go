@@ -0,0 +1,679 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/hamzabow/co/internal/git"
+	"github.com/hamzabow/co/internal/gitdiff"
+	"github.com/hamzabow/co/internal/lint"
+	"github.com/hamzabow/co/internal/prompts"
+	"github.com/hamzabow/co/internal/provider"
+	"github.com/hamzabow/co/internal/secrets"
+)
+
+// ErrUnknownSetting is returned when a setting key doesn't exist
+var ErrUnknownSetting = errors.New("unknown setting")
+
+// Origins of a resolved setting value
+const (
+	OriginDefault = "default"
+	OriginUser    = "user config"
+	OriginRepo    = "repo config"
+	OriginEnv     = "env"
+	OriginFlag    = "flag"
+)
+
+// Settings holds non-secret preferences stored in plain text next to the encrypted credentials.
+// Every field is addressable as a dotted key (e.g. "ui.skip_editor") built from the toml tags,
+// and can be overridden with an environment variable named CO_<KEY> (e.g. CO_UI_SKIP_EDITOR).
+type Settings struct {
+	Provider         string          `toml:"provider" desc:"AI provider (openai, anthropic, ollama)"`
+	Model            string          `toml:"model" desc:"Model name, empty uses the provider default"`
+	BaseURL          string          `toml:"base_url" desc:"API endpoint, empty uses the provider default"`
+	Headers          []string        `toml:"headers" list:"lines" desc:"Extra HTTP headers sent with every request, as \"Name: Value\", one per line"`
+	Format           string          `toml:"format" desc:"Commit message format or custom template name"`
+	Language         string          `toml:"language" desc:"Language the commit message is written in"`
+	Temperature      *float64        `toml:"temperature" desc:"Sampling temperature, empty uses the provider default"`
+	Timeout          time.Duration   `toml:"timeout" desc:"Maximum time to wait for the provider, e.g. 90s or 2m, 0 disables it"`
+	Retries          int             `toml:"retries" desc:"Number of retries for rate limits, server and network errors"`
+	RetryBackoff     time.Duration   `toml:"retry_backoff" desc:"Delay before the first retry, doubled for each further retry"`
+	Candidates       int             `toml:"candidates" desc:"Number of alternative messages to generate and pick from, ignored when skip_editor is set"`
+	Stream           bool            `toml:"stream" desc:"Stream the response and preview it while it is generated"`
+	MaxDiffSize      int             `toml:"max_diff_size" desc:"Maximum diff size in bytes sent to the model, 0 means limited by the context window only"`
+	ContextWindow    int             `toml:"context_window" desc:"Model context window in tokens used to budget the diff, 0 looks it up from the model name"`
+	Summarize        string          `toml:"summarize" desc:"Summarize diffs too large for the model in parts: never, auto or always"`
+	SummarizeBy      string          `toml:"summarize_by" desc:"Group the files summarized together by file or dir"`
+	SummarizeWorkers int             `toml:"summarize_workers" desc:"Maximum number of concurrent summary requests"`
+	Exclude          []string        `toml:"exclude" desc:"Glob patterns of files whose diff isn't sent to the model, comma separated"`
+	DefaultExcludes  bool            `toml:"default_excludes" desc:"Also exclude lock files, generated and vendored code and binaries"`
+	Secrets          string          `toml:"secrets" desc:"What to do with secrets found in the diff: redact, block or off"`
+	Scopes           []string        `toml:"scopes" desc:"Allowed commit scopes, comma separated"`
+	Context          ContextSettings `toml:"context"`
+	Ticket           TicketSettings  `toml:"ticket"`
+	Lint             LintSettings    `toml:"lint"`
+	Azure            AzureSettings   `toml:"azure"`
+	UI               UISettings      `toml:"ui"`
+}
+
+// ContextSettings toggles the repository context given to the model besides the diff
+type ContextSettings struct {
+	Branch        bool `toml:"branch" desc:"Include the current branch name"`
+	Stat          bool `toml:"stat" desc:"Include the output of git diff --staged --stat"`
+	Files         bool `toml:"files" desc:"Include the renamed and deleted files, and the staged files when stat is off"`
+	RecentCommits int  `toml:"recent_commits" desc:"Number of recent commit subjects to include, 0 disables them"`
+	Examples      int  `toml:"examples" desc:"Number of past commit messages of the default branch to include as style examples, 0 disables them"`
+}
+
+// TicketSettings configures ticket references taken from the branch name
+type TicketSettings struct {
+	Pattern   string `toml:"pattern" desc:"Regular expression matching ticket IDs in the branch name, its first group is the ID if it has one"`
+	Placement string `toml:"placement" desc:"Where to add ticket references: none, prefix, scope or footer"`
+	Template  string `toml:"template" desc:"Go template of the inserted text using .ID, .IDs, .Branch and .Scope, empty uses the placement's default"`
+}
+
+// LintSettings configures the rules commit messages are checked against
+type LintSettings struct {
+	Conventional      bool     `toml:"conventional" desc:"Require a \"type(scope): subject\" header with the conventional formats and custom templates"`
+	Types             []string `toml:"types" desc:"Allowed Conventional Commits types, comma separated"`
+	RequireScope      bool     `toml:"require_scope" desc:"Require a Conventional Commits scope, one of scopes if set"`
+	SubjectMaxLength  int      `toml:"subject_max_length" desc:"Maximum length of the subject line, 0 disables the check"`
+	BodyMaxLineLength int      `toml:"body_max_line_length" desc:"Maximum length of body lines, 0 disables the check"`
+	Imperative        bool     `toml:"imperative" desc:"Warn about subjects not written in the imperative mood"`
+	RequiredFooters   []string `toml:"required_footers" desc:"Footers every message must have, e.g. Signed-off-by, comma separated"`
+	Disable           []string `toml:"disable" desc:"Lint rules to turn off, comma separated"`
+	MaxAttempts       int      `toml:"max_attempts" desc:"Requests made at most to get a generated message without lint errors, 1 disables regeneration"`
+}
+
+// AzureSettings configures the openai provider to talk to Azure OpenAI
+type AzureSettings struct {
+	Deployment string `toml:"deployment" desc:"Azure OpenAI deployment, enables Azure mode with base_url as the resource endpoint"`
+	APIVersion string `toml:"api_version" desc:"Azure OpenAI API version"`
+}
+
+// UISettings holds options for the interactive parts of co
+type UISettings struct {
+	SkipEditor     bool `toml:"skip_editor" desc:"Commit the generated message without opening the editor"`
+	AutoStage      bool `toml:"auto_stage" desc:"Stage all changes without asking when nothing is staged"`
+	ExternalEditor bool `toml:"external_editor" desc:"Edit the generated message in the editor git uses (GIT_EDITOR, core.editor, VISUAL, EDITOR) instead of the built-in one"`
+	SubjectLimit   int  `toml:"subject_limit" desc:"Subject length the editor's status line counts against, 0 hides the count"`
+	WrapWidth      int  `toml:"wrap_width" desc:"Width the editor hard-wraps the body at with Alt+Q"`
+}
+
+// Modes for summarizing diffs too large for the model in parts
+const (
+	// SummarizeNever trims large diffs to fit the context window
+	SummarizeNever = "never"
+	// SummarizeAuto summarizes diffs that don't fit the context window
+	SummarizeAuto = "auto"
+	// SummarizeAlways summarizes every diff, even small ones
+	SummarizeAlways = "always"
+)
+
+// DefaultSummarizeWorkers is the default number of concurrent summary requests
+const DefaultSummarizeWorkers = 4
+
+// Placements of ticket references in the commit message
+const (
+	// TicketNone leaves the message unchanged
+	TicketNone = "none"
+	// TicketPrefix puts the reference at the start of the subject
+	TicketPrefix = "prefix"
+	// TicketScope uses the reference as the Conventional Commits scope
+	TicketScope = "scope"
+	// TicketFooter adds the reference as a footer
+	TicketFooter = "footer"
+)
+
+// TicketPlacements returns the supported ticket placements
+func TicketPlacements() []string {
+	return []string{TicketNone, TicketPrefix, TicketScope, TicketFooter}
+}
+
+// DefaultTicketPattern matches Jira style issue keys such as PROJ-1234
+const DefaultTicketPattern = `[A-Z][A-Z0-9]+-[0-9]+`
+
+const (
+	// DefaultSubjectLimit is the subject length the editor's status line counts against
+	DefaultSubjectLimit = 50
+	// DefaultWrapWidth is the width the editor hard-wraps the body at
+	DefaultWrapWidth = 72
+)
+
+// DefaultSettings returns the settings used when nothing is configured
+func DefaultSettings() Settings {
+	lintDefaults := lint.DefaultConfig()
+	return Settings{
+		Provider:         provider.OpenAI,
+		Format:           prompts.DefaultFormat,
+		Timeout:          2 * time.Minute,
+		Retries:          provider.DefaultRetryPolicy.MaxRetries,
+		RetryBackoff:     provider.DefaultRetryPolicy.InitialBackoff,
+		Stream:           true,
+		Candidates:       1,
+		Summarize:        SummarizeNever,
+		SummarizeBy:      string(gitdiff.ByDir),
+		SummarizeWorkers: DefaultSummarizeWorkers,
+		DefaultExcludes:  true,
+		Secrets:          secrets.ModeRedact,
+		Context: ContextSettings{
+			Branch:        true,
+			Stat:          true,
+			Files:         true,
+			RecentCommits: 10,
+		},
+		Ticket: TicketSettings{
+			Pattern:   DefaultTicketPattern,
+			Placement: TicketNone,
+		},
+		Lint: LintSettings{
+			Conventional:      lintDefaults.Conventional,
+			Types:             lintDefaults.Types,
+			SubjectMaxLength:  lintDefaults.SubjectMaxLength,
+			BodyMaxLineLength: lintDefaults.BodyMaxLineLength,
+			Imperative:        lintDefaults.Imperative,
+			MaxAttempts:       2,
+		},
+		Azure: AzureSettings{
+			APIVersion: provider.DefaultAzureAPIVersion,
+		},
+		UI: UISettings{
+			SubjectLimit: DefaultSubjectLimit,
+			WrapWidth:    DefaultWrapWidth,
+		},
+	}
+}
+
+// Key describes a single setting
+type Key struct {
+	// Name is the dotted key used by `co config` and in the settings file
+	Name string
+	// Env is the environment variable overriding the setting
+	Env string
+	// Description is a short human readable explanation
+	Description string
+
+	index []int
+	// lines is set for lists whose items may contain commas, so that only
+	// newlines separate them
+	lines bool
+}
+
+// keys lists all settings, derived once from the Settings struct
+var keys = buildKeys(reflect.TypeOf(Settings{}), "", nil)
+
+func buildKeys(t reflect.Type, prefix string, index []int) []Key {
+	var result []Key
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := prefix + strings.Split(field.Tag.Get("toml"), ",")[0]
+		fieldIndex := append(append([]int{}, index...), i)
+
+		// Nested structs become sections in the settings file
+		if field.Type.Kind() == reflect.Struct {
+			result = append(result, buildKeys(field.Type, name+".", fieldIndex)...)
+			continue
+		}
+
+		result = append(result, Key{
+			Name:        name,
+			Env:         "CO_" + strings.ToUpper(strings.ReplaceAll(name, ".", "_")),
+			Description: field.Tag.Get("desc"),
+			index:       fieldIndex,
+			lines:       field.Tag.Get("list") == "lines",
+		})
+	}
+	return result
+}
+
+// Keys returns all known settings in declaration order
+func Keys() []Key {
+	return append([]Key{}, keys...)
+}
+
+// LookupKey returns the setting with the given dotted name
+func LookupKey(name string) (Key, error) {
+	for _, k := range keys {
+		if k.Name == name {
+			return k, nil
+		}
+	}
+	return Key{}, fmt.Errorf("%w: %q", ErrUnknownSetting, name)
+}
+
+// field returns the struct field backing the key
+func (s *Settings) field(k Key) reflect.Value {
+	return reflect.ValueOf(s).Elem().FieldByIndex(k.index)
+}
+
+// Get returns the value of a setting formatted as a string (empty when unset)
+func (s *Settings) Get(name string) (string, error) {
+	k, err := LookupKey(name)
+	if err != nil {
+		return "", err
+	}
+	return formatValue(s.field(k)), nil
+}
+
+// Set parses raw according to the setting's type and assigns it.
+// Lists are given as comma separated values, or one per line when items may
+// contain commas (always for headers); an empty string clears optional values.
+func (s *Settings) Set(name, raw string) error {
+	k, err := LookupKey(name)
+	if err != nil {
+		return err
+	}
+	if err := parseInto(s.field(k), raw, k.lines); err != nil {
+		return fmt.Errorf("invalid value for %s: %w", name, err)
+	}
+	return nil
+}
+
+// durationType is handled separately as its kind is a plain int64
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func parseInto(field reflect.Value, raw string, lines bool) error {
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("expected a duration such as 30s or 2m, got %q", raw)
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.Pointer:
+		if raw == "" {
+			field.Set(reflect.Zero(field.Type()))
+			return nil
+		}
+		value := reflect.New(field.Type().Elem())
+		if err := parseInto(value.Elem(), raw, lines); err != nil {
+			return err
+		}
+		field.Set(value)
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("expected true or false, got %q", raw)
+		}
+		field.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("expected an integer, got %q", raw)
+		}
+		field.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("expected a number, got %q", raw)
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		separator := ","
+		if lines || strings.Contains(raw, "\n") {
+			separator = "\n"
+		}
+		var items []string
+		for _, item := range strings.Split(raw, separator) {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported setting type %s", field.Type())
+	}
+	return nil
+}
+
+// assign stores a value decoded from a TOML file into the field
+func assign(field reflect.Value, value any) error {
+	// Durations are written as strings such as "90s"
+	if field.Type() == durationType {
+		raw, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("expected a duration string such as \"30s\", got %v", value)
+		}
+		return parseInto(field, raw, false)
+	}
+
+	switch field.Kind() {
+	case reflect.Pointer:
+		elem := reflect.New(field.Type().Elem())
+		if err := assign(elem.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+	case reflect.Slice:
+		list, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("expected a list, got %v", value)
+		}
+		items := make([]string, 0, len(list))
+		for _, item := range list {
+			s, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("expected a list of strings, got %v", value)
+			}
+			items = append(items, s)
+		}
+		field.Set(reflect.ValueOf(items))
+		return nil
+	case reflect.Float64:
+		// TOML integers are accepted where a float is expected
+		if n, ok := value.(int64); ok {
+			value = float64(n)
+		}
+	case reflect.Int:
+		if n, ok := value.(int64); ok {
+			field.SetInt(n)
+			return nil
+		}
+	}
+
+	v := reflect.ValueOf(value)
+	if !v.Type().AssignableTo(field.Type()) {
+		return fmt.Errorf("expected %s, got %v", field.Type(), value)
+	}
+	field.Set(v)
+	return nil
+}
+
+func formatValue(field reflect.Value) string {
+	switch field.Kind() {
+	case reflect.Pointer:
+		if field.IsNil() {
+			return ""
+		}
+		return formatValue(field.Elem())
+	case reflect.Slice:
+		items := make([]string, field.Len())
+		for i := range items {
+			items[i] = fmt.Sprint(field.Index(i).Interface())
+		}
+		return strings.Join(items, ",")
+	default:
+		return fmt.Sprint(field.Interface())
+	}
+}
+
+// fileValue returns the field's value in a form suitable for TOML encoding
+func fileValue(field reflect.Value) any {
+	if field.Type() == durationType {
+		return time.Duration(field.Int()).String()
+	}
+	if field.Kind() == reflect.Pointer {
+		return field.Elem().Interface()
+	}
+	return field.Interface()
+}
+
+// GetSettingsFilePath returns the path to the user settings file
+func GetSettingsFilePath() (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(configDir, "config.toml"), nil
+}
+
+// RepoSettingsFileNames lists the repository-local settings files, relative to the
+// git top-level, in the order they are looked up. Only the first one found is used.
+var RepoSettingsFileNames = []string{".co.toml", filepath.Join(".co", "config.toml")}
+
+// GetRepoSettingsFilePath returns the repository-local settings file of the current
+// git repository, or an empty string when there is none
+func GetRepoSettingsFilePath() (string, error) {
+	topLevel, err := git.TopLevel()
+	if err != nil {
+		// Not inside a git repository
+		return "", nil
+	}
+
+	for _, name := range RepoSettingsFileNames {
+		path := filepath.Join(topLevel, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", nil
+}
+
+// readSettingsFile decodes a settings file into nested maps, returning an empty map when it doesn't exist
+func readSettingsFile(path string) (map[string]any, error) {
+	values := make(map[string]any)
+	if _, err := toml.DecodeFile(path, &values); err != nil {
+		if os.IsNotExist(err) {
+			return values, nil
+		}
+		return nil, err
+	}
+	return values, nil
+}
+
+// flatten turns nested TOML tables into dotted keys
+func flatten(prefix string, values map[string]any, out map[string]any) {
+	for k, v := range values {
+		if table, ok := v.(map[string]any); ok {
+			flatten(prefix+k+".", table, out)
+			continue
+		}
+		out[prefix+k] = v
+	}
+}
+
+// repoKeys lists the settings a repository settings file may set, as names or
+// section prefixes. Anything else could send the user's requests and API key
+// elsewhere (provider, base_url, headers, azure.*) or weaken their protections
+// (secrets, ui.*), which a cloned repository must not be able to do.
+var repoKeys = []string{"format", "language", "scopes", "exclude", "context.", "lint.", "ticket."}
+
+// RepoAllowed reports whether a repository settings file may set the setting
+func RepoAllowed(name string) bool {
+	for _, k := range repoKeys {
+		if name == k || (strings.HasSuffix(k, ".") && strings.HasPrefix(name, k)) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyFile merges the values of a settings file into the resolved settings,
+// recording their origin. Settings rejected by allowed are skipped and
+// recorded as ignored; a nil allowed accepts all settings.
+func (r *Resolved) applyFile(path, origin string, allowed func(string) bool) error {
+	values, err := readSettingsFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	flat := make(map[string]any)
+	flatten("", values, flat)
+
+	for name, value := range flat {
+		k, err := LookupKey(name)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if allowed != nil && !allowed(name) {
+			r.Ignored = append(r.Ignored, name)
+			continue
+		}
+		if err := assign(r.Settings.field(k), value); err != nil {
+			return fmt.Errorf("%s: invalid value for %s: %w", path, name, err)
+		}
+		r.origins[name] = origin
+	}
+	return nil
+}
+
+// Resolved holds the effective settings along with where each value came from
+type Resolved struct {
+	Settings
+	// IgnoredPath is the repository settings file, when it set settings it may not set
+	IgnoredPath string
+	// Ignored lists those settings, sorted
+	Ignored []string
+
+	origins map[string]string
+}
+
+// Origin describes where the effective value of a setting came from
+func (r *Resolved) Origin(name string) string {
+	if origin, ok := r.origins[name]; ok {
+		return origin
+	}
+	return OriginDefault
+}
+
+// Resolve computes the effective settings. Later sources take precedence:
+// defaults, the user settings file, the repository settings file (for the
+// settings RepoAllowed accepts), CO_* environment variables, and finally
+// flags, given as a map from setting key to raw value. CO_* variables are
+// only read from the real environment, never from a .env file.
+func Resolve(flags map[string]string) (*Resolved, error) {
+	r := &Resolved{
+		Settings: DefaultSettings(),
+		origins:  make(map[string]string),
+	}
+
+	settingsPath, err := GetSettingsFilePath()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.applyFile(settingsPath, OriginUser+" ("+settingsPath+")", nil); err != nil {
+		return nil, err
+	}
+
+	// Team-wide settings checked into the repository override personal ones
+	repoPath, err := GetRepoSettingsFilePath()
+	if err != nil {
+		return nil, err
+	}
+	if repoPath != "" {
+		if err := r.applyFile(repoPath, OriginRepo+" ("+repoPath+")", RepoAllowed); err != nil {
+			return nil, err
+		}
+		if len(r.Ignored) > 0 {
+			r.IgnoredPath = repoPath
+			sort.Strings(r.Ignored)
+		}
+	}
+
+	for _, k := range keys {
+		if raw, ok := os.LookupEnv(k.Env); ok {
+			if err := r.Set(k.Name, raw); err != nil {
+				return nil, fmt.Errorf("%s: %w", k.Env, err)
+			}
+			r.origins[k.Name] = OriginEnv + " (" + k.Env + ")"
+		}
+	}
+
+	// Apply flags in a stable order so errors are deterministic
+	names := make([]string, 0, len(flags))
+	for name := range flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := r.Set(name, flags[name]); err != nil {
+			return nil, err
+		}
+		r.origins[name] = OriginFlag
+	}
+
+	return r, nil
+}
+
+// SetUserSetting validates raw and stores it in the user settings file
+func SetUserSetting(name, raw string) error {
+	// Parse into a scratch value to validate and convert to the right type
+	var scratch Settings
+	if err := scratch.Set(name, raw); err != nil {
+		return err
+	}
+	k, _ := LookupKey(name)
+
+	return updateUserSettings(func(values map[string]any) {
+		table := values
+		parts := strings.Split(name, ".")
+		for _, part := range parts[:len(parts)-1] {
+			next, ok := table[part].(map[string]any)
+			if !ok {
+				next = make(map[string]any)
+				table[part] = next
+			}
+			table = next
+		}
+
+		field := scratch.field(k)
+		if field.Kind() == reflect.Pointer && field.IsNil() {
+			delete(table, parts[len(parts)-1])
+			return
+		}
+		table[parts[len(parts)-1]] = fileValue(field)
+	})
+}
+
+// UnsetUserSetting removes a setting from the user settings file so the default applies again
+func UnsetUserSetting(name string) error {
+	if _, err := LookupKey(name); err != nil {
+		return err
+	}
+
+	return updateUserSettings(func(values map[string]any) {
+		table := values
+		parts := strings.Split(name, ".")
+		for _, part := range parts[:len(parts)-1] {
+			next, ok := table[part].(map[string]any)
+			if !ok {
+				return
+			}
+			table = next
+		}
+		delete(table, parts[len(parts)-1])
+
+		// Drop sections left empty
+		if len(parts) > 1 && len(table) == 0 {
+			delete(values, parts[0])
+		}
+	})
+}
+
+// updateUserSettings reads the user settings file, applies update and writes it back
+func updateUserSettings(update func(values map[string]any)) error {
+	settingsPath, err := GetSettingsFilePath()
+	if err != nil {
+		return err
+	}
+
+	values, err := readSettingsFile(settingsPath)
+	if err != nil {
+		return err
+	}
+
+	update(values)
+
+	file, err := os.OpenFile(settingsPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return toml.NewEncoder(file).Encode(values)
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestRepoAllowed(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"format", true},
+		{"language", true},
+		{"scopes", true},
+		{"exclude", true},
+		{"context.examples", true},
+		{"lint.types", true},
+		{"ticket.placement", true},
+		{"provider", false},
+		{"model", false},
+		{"base_url", false},
+		{"headers", false},
+		{"azure.deployment", false},
+		{"secrets", false},
+		{"ui.skip_editor", false},
+		{"ui.external_editor", false},
+		{"formatting", false},
+		{"lint", false},
+	}
+	for _, tt := range tests {
+		if got := RepoAllowed(tt.name); got != tt.want {
+			t.Errorf("RepoAllowed(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestApplyFileIgnoresDisallowedSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".co.toml")
+	content := `base_url = "https://evil.example/v1"
+secrets = "off"
+format = "simple"
+
+[ui]
+skip_editor = true
+
+[lint]
+imperative = true
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	r := &Resolved{Settings: DefaultSettings(), origins: make(map[string]string)}
+	if err := r.applyFile(path, OriginRepo, RepoAllowed); err != nil {
+		t.Fatal(err)
+	}
+
+	defaults := DefaultSettings()
+	if r.BaseURL != defaults.BaseURL || r.Secrets != defaults.Secrets || r.UI.SkipEditor != defaults.UI.SkipEditor {
+		t.Errorf("disallowed settings were applied: base_url=%q secrets=%q ui.skip_editor=%v", r.BaseURL, r.Secrets, r.UI.SkipEditor)
+	}
+	if r.Format != "simple" || !r.Lint.Imperative {
+		t.Errorf("allowed settings were not applied: format=%q lint.imperative=%v", r.Format, r.Lint.Imperative)
+	}
+
+	slices.Sort(r.Ignored)
+	if want := []string{"base_url", "secrets", "ui.skip_editor"}; !slices.Equal(r.Ignored, want) {
+		t.Errorf("Ignored = %v, want %v", r.Ignored, want)
+	}
+	if origin := r.Origin("base_url"); origin != OriginDefault {
+		t.Errorf("Origin(base_url) = %q, want %q", origin, OriginDefault)
+	}
+}
+
+func TestSetList(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want []string
+	}{
+		{"scopes", "api, ui,,db", []string{"api", "ui", "db"}},
+		{"scopes", "api\nui", []string{"api", "ui"}},
+		{"exclude", "", nil},
+		// Header values may contain commas
+		{"headers", "X-Foo: a, b", []string{"X-Foo: a, b"}},
+		{"headers", "X-Foo: a, b\nX-Bar: c\n", []string{"X-Foo: a, b", "X-Bar: c"}},
+	}
+
+	for _, tt := range tests {
+		s := DefaultSettings()
+		if err := s.Set(tt.name, tt.raw); err != nil {
+			t.Fatal(err)
+		}
+		k, _ := LookupKey(tt.name)
+		if got := s.field(k).Interface().([]string); !slices.Equal(got, tt.want) {
+			t.Errorf("Set(%s, %q) = %q, want %q", tt.name, tt.raw, got, tt.want)
+		}
+	}
+}
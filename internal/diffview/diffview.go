@@ -0,0 +1,197 @@
+package diffview
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hamzabow/co/internal/gitdiff"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// tabWidth is the number of spaces tabs are expanded to, so that lines can be cut to the pane width
+const tabWidth = 4
+
+var (
+	// File title style
+	fileStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FAFAFA")).
+			Background(lipgloss.Color("#7D56F4")).
+			Bold(true)
+
+	// Diff line styles
+	addedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#50FA7B"))
+	deletedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5555"))
+	hunkStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#8BE9FD"))
+	contextStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#AAAAAA"))
+	noteStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#626262"))
+
+	// Pane border, highlighted while the pane has focus
+	paneStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("#626262"))
+	focusedPaneStyle = paneStyle.BorderForeground(lipgloss.Color("#7D56F4"))
+
+	// Header line above the diff
+	headerStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#626262"))
+)
+
+// Model shows a diff with colored lines, scrolling and jumping between files
+type Model struct {
+	viewport viewport.Model
+	files    []gitdiff.File
+	// offsets holds the line at which each file starts in the rendered diff
+	offsets []int
+	focused bool
+}
+
+// New creates a view of the diff, as printed by git diff
+func New(diff string) Model {
+	m := Model{
+		viewport: viewport.New(40, 10),
+		files:    gitdiff.Parse(diff),
+	}
+	m.render()
+	return m
+}
+
+// SetSize sets the outer width and height of the pane, including its header and border
+func (m *Model) SetSize(width, height int) {
+	m.viewport.Width = max(width-2, 10)
+	m.viewport.Height = max(height-3, 1)
+	m.render()
+}
+
+// Focus makes the pane handle scrolling and navigation keys
+func (m *Model) Focus() {
+	m.focused = true
+}
+
+// Blur stops the pane from handling keys
+func (m *Model) Blur() {
+	m.focused = false
+}
+
+// Focused reports whether the pane handles keys
+func (m Model) Focused() bool {
+	return m.focused
+}
+
+// render colors the diff and cuts its lines to the width of the pane
+func (m *Model) render() {
+	var lines []string
+	m.offsets = m.offsets[:0]
+	width := m.viewport.Width
+
+	for _, f := range m.files {
+		m.offsets = append(m.offsets, len(lines))
+		title := fmt.Sprintf(" %s +%d -%d ", f.Path, f.Added, f.Deleted)
+		lines = append(lines, fileStyle.Render(cut(title, width)))
+
+		if f.Binary {
+			lines = append(lines, noteStyle.Render(cut("Binary file", width)))
+		}
+		for _, hunk := range f.Hunks {
+			for _, line := range strings.Split(strings.TrimRight(hunk, "\n"), "\n") {
+				lines = append(lines, styleOf(line).Render(cut(line, width)))
+			}
+		}
+		lines = append(lines, "")
+	}
+
+	if len(m.files) == 0 {
+		lines = append(lines, noteStyle.Render("No staged changes"))
+	}
+	m.viewport.SetContent(strings.Join(lines, "\n"))
+}
+
+// styleOf returns the style of a line of a hunk
+func styleOf(line string) lipgloss.Style {
+	switch {
+	case strings.HasPrefix(line, "@@"):
+		return hunkStyle
+	case strings.HasPrefix(line, "+"):
+		return addedStyle
+	case strings.HasPrefix(line, "-"):
+		return deletedStyle
+	case strings.HasPrefix(line, `\`):
+		// "\ No newline at end of file"
+		return noteStyle
+	default:
+		return contextStyle
+	}
+}
+
+// cut expands tabs and shortens line to width, as wrapped lines would throw off scrolling
+func cut(line string, width int) string {
+	runes := []rune(strings.ReplaceAll(line, "\t", strings.Repeat(" ", tabWidth)))
+	if len(runes) > width {
+		return string(runes[:max(width-1, 0)]) + "…"
+	}
+	return string(runes)
+}
+
+// current returns the index of the file at the top of the pane
+func (m Model) current() int {
+	current := 0
+	for i, offset := range m.offsets {
+		if offset <= m.viewport.YOffset {
+			current = i
+		}
+	}
+	return current
+}
+
+// Update scrolls the diff and moves between files while the pane has focus:
+// n/] and p/[ jump to the next and previous file, g/G to the top and bottom.
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	if !m.focused {
+		return m, nil
+	}
+
+	if msg, ok := msg.(tea.KeyMsg); ok && len(m.offsets) > 0 {
+		current := m.current()
+		switch msg.String() {
+		case "n", "]":
+			if current < len(m.offsets)-1 {
+				m.viewport.SetYOffset(m.offsets[current+1])
+			}
+			return m, nil
+		case "p", "[":
+			// Go back to the start of the current file unless already there
+			if m.viewport.YOffset == m.offsets[current] && current > 0 {
+				current--
+			}
+			m.viewport.SetYOffset(m.offsets[current])
+			return m, nil
+		case "g", "home":
+			m.viewport.GotoTop()
+			return m, nil
+		case "G", "end":
+			m.viewport.GotoBottom()
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+// View renders the pane with a header naming the file at the top
+func (m Model) View() string {
+	header := "Staged changes"
+	if len(m.files) > 0 {
+		current := m.current()
+		header = fmt.Sprintf("Staged changes · file %d/%d · %d%%", current+1, len(m.files), int(m.viewport.ScrollPercent()*100))
+	}
+
+	style := paneStyle
+	if m.focused {
+		style = focusedPaneStyle
+	}
+	return headerStyle.Render(cut(header, m.viewport.Width+2)) + "\n" + style.Render(m.viewport.View())
+}
@@ -0,0 +1,131 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/hamzabow/co/internal/git"
+)
+
+// ErrEditorFailed is returned when the editor can't be started or exits with an error
+var ErrEditorFailed = errors.New("editor failed")
+
+// scissors marks the start of text git ignores, like the diff added by git commit --verbose
+const scissors = " ------------------------ >8 ------------------------"
+
+// fileName is the name git gives the message file, which editors recognize
+const fileName = "COMMIT_EDITMSG"
+
+// Draft is a commit message written to a file for editing
+type Draft struct {
+	// Path is the path of the file holding the message
+	Path string
+
+	dir     string
+	comment string
+}
+
+// NewDraft writes message to a file in a new temporary directory, followed
+// by git's instructions as comments
+func NewDraft(message string) (*Draft, error) {
+	dir, err := os.MkdirTemp("", "co-")
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Draft{Path: filepath.Join(dir, fileName), dir: dir, comment: git.CommentChar()}
+	text := strings.TrimRight(message, "\n") + "\n\n" +
+		d.comment + " Please enter the commit message for your changes. Lines starting\n" +
+		d.comment + " with '" + d.comment + "' will be ignored, and an empty message aborts the commit.\n"
+	if err := os.WriteFile(d.Path, []byte(text), 0o600); err != nil {
+		d.Remove()
+		return nil, err
+	}
+	return d, nil
+}
+
+// Read returns the edited message, cleaned up like git does
+func (d *Draft) Read() (string, error) {
+	text, err := os.ReadFile(d.Path)
+	if err != nil {
+		return "", err
+	}
+	return Cleanup(string(text), d.comment), nil
+}
+
+// Remove deletes the draft and its directory
+func (d *Draft) Remove() error {
+	return os.RemoveAll(d.dir)
+}
+
+// Command returns the command opening path in the editor git uses. Like
+// git, the editor is run by the shell, as it may include arguments.
+func Command(path string) (*exec.Cmd, error) {
+	name, err := git.Editor()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEditorFailed, err)
+	}
+
+	if _, err := exec.LookPath("sh"); err != nil {
+		// Without a shell, e.g. on Windows outside of Git Bash, split the arguments on spaces
+		args := strings.Fields(name)
+		if len(args) == 0 {
+			return nil, fmt.Errorf("%w: no editor configured", ErrEditorFailed)
+		}
+		return exec.Command(args[0], append(args[1:], path)...), nil
+	}
+	return exec.Command("sh", "-c", name+` "$@"`, name, path), nil
+}
+
+// Edit opens message in the editor with the terminal attached and returns
+// the edited message. An empty result means the user aborted.
+func Edit(message string) (string, error) {
+	d, err := NewDraft(message)
+	if err != nil {
+		return "", err
+	}
+	defer d.Remove()
+
+	cmd, err := Command(d.Path)
+	if err != nil {
+		return "", err
+	}
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrEditorFailed, err)
+	}
+	return d.Read()
+}
+
+// Cleanup strips the message like git commit does by default: text from the
+// scissors line on and lines starting with the comment character are removed,
+// as are trailing whitespace, repeated blank lines, and leading and trailing
+// blank lines.
+func Cleanup(message, comment string) string {
+	var lines []string
+	blank := false
+	for _, line := range strings.Split(message, "\n") {
+		if line == comment+scissors {
+			break
+		}
+		if strings.HasPrefix(line, comment) {
+			continue
+		}
+
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			blank = len(lines) > 0
+			continue
+		}
+		if blank {
+			lines = append(lines, "")
+			blank = false
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
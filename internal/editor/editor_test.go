@@ -0,0 +1,77 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCleanup(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		comment string
+		want    string
+	}{
+		{"clean", "feat: add login\n\nWith validation.", "#", "feat: add login\n\nWith validation."},
+		{"comments", "# note\nfeat: add login\n# Please enter the commit message\n", "#", "feat: add login"},
+		{"comment in the middle", "fix: x\n\nbody\n# hidden\nmore", "#", "fix: x\n\nbody\nmore"},
+		{"indented hash kept", "fix: x\n\n  # not a comment", "#", "fix: x\n\n  # not a comment"},
+		{"custom comment char", "fix: x\n\n#123 is fixed\n; instructions", ";", "fix: x\n\n#123 is fixed"},
+		{"multi-character comment", "fix: x\n// hidden\n/ kept", "//", "fix: x\n/ kept"},
+		{"scissors", "fix: x\n\nbody\n# ------------------------ >8 ------------------------\ndiff --git a/x b/x\n+fix: y", "#", "fix: x\n\nbody"},
+		{"scissors with custom comment char", "fix: x\n; ------------------------ >8 ------------------------\n+y", ";", "fix: x"},
+		{"scissors of another comment char kept", "fix: x\n\n; ------------------------ >8 ------------------------", "#", "fix: x\n\n; ------------------------ >8 ------------------------"},
+		{"trailing whitespace", "fix: x  \t\r\n\r\nbody \n", "#", "fix: x\n\nbody"},
+		{"repeated blank lines", "\n\nfix: x\n\n\n\nbody\n\n\n", "#", "fix: x\n\nbody"},
+		{"blank line left by a comment", "fix: x\n# a\n\n# b\n\nbody", "#", "fix: x\n\nbody"},
+		{"only comments", "# a\n\n# b\n", "#", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Cleanup(tt.message, tt.comment); got != tt.want {
+				t.Errorf("Cleanup(%q, %q) = %q, want %q", tt.message, tt.comment, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDraftRead(t *testing.T) {
+	dir := t.TempDir()
+	d := &Draft{Path: filepath.Join(dir, fileName), dir: dir, comment: ";"}
+	text := "fix: x\n\n#42 done\n; Please enter the commit message\n"
+	if err := os.WriteFile(d.Path, []byte(text), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := d.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "fix: x\n\n#42 done"; got != want {
+		t.Errorf("Read() = %q, want %q", got, want)
+	}
+}
+
+func TestNewDraft(t *testing.T) {
+	d, err := NewDraft("feat: add login\n\nWith validation.\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := d.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "feat: add login\n\nWith validation."; got != want {
+		t.Errorf("Read() = %q, want the message without git's instructions %q", got, want)
+	}
+
+	if err := d.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Dir(d.Path)); !os.IsNotExist(err) {
+		t.Errorf("draft directory still exists after Remove: %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package genmessage
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// preambleLine matches a line introducing the message, e.g. "Here is your commit message:"
+	preambleLine = regexp.MustCompile(`(?i)^\s*(?:(?:here(?:'s| is| are)|sure|certainly|okay|ok|of course)\b.*|(?:suggested |generated |proposed )?commit message)\s*:\s*$`)
+
+	// inlinePreamble matches a label put in front of the message on the same line
+	inlinePreamble = regexp.MustCompile(`(?i)^\s*(?:suggested |generated |proposed )?commit message\s*:\s+`)
+
+	// fencedBlock matches a code block making up the whole text, with an optional language tag
+	fencedBlock = regexp.MustCompile("(?s)^```[\\w-]*[ \\t]*\\n(.*?)\\n?```$")
+)
+
+// quotePairs are the quotes models sometimes wrap the whole message in
+var quotePairs = [][2]string{{`"`, `"`}, {"'", "'"}, {"`", "`"}, {"“", "”"}}
+
+// cleanResponse removes what models add around a commit message despite the
+// instructions: code fences, preambles such as "Here is your commit
+// message:", and quotes around the whole message
+func cleanResponse(response string) string {
+	message := strings.TrimSpace(response)
+
+	// A fenced block wrapping the whole response, possibly after a preamble,
+	// holds the message. A block further down is part of the message itself.
+	fenced := message
+	if first, rest, ok := strings.Cut(message, "\n"); ok && preambleLine.MatchString(first) {
+		fenced = strings.TrimSpace(rest)
+	}
+	if m := fencedBlock.FindStringSubmatch(fenced); m != nil {
+		message = strings.TrimSpace(m[1])
+	}
+
+	if first, rest, ok := strings.Cut(message, "\n"); ok && preambleLine.MatchString(first) {
+		message = strings.TrimSpace(rest)
+	}
+	message = inlinePreamble.ReplaceAllString(message, "")
+
+	for _, quotes := range quotePairs {
+		inner, ok := strings.CutPrefix(message, quotes[0])
+		if !ok {
+			continue
+		}
+		if inner, ok = strings.CutSuffix(inner, quotes[1]); ok && !strings.Contains(inner, quotes[0]) {
+			message = strings.TrimSpace(inner)
+			break
+		}
+	}
+
+	return message
+}
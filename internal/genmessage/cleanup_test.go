@@ -0,0 +1,38 @@
+package genmessage
+
+import "testing"
+
+func TestCleanResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     string
+	}{
+		{"clean", "feat: add login\n\nWith validation.", "feat: add login\n\nWith validation."},
+		{"surrounding whitespace", "\n\n  fix: typo  \n\n", "fix: typo"},
+		{"fenced", "```\nfeat: add login\n```", "feat: add login"},
+		{"fenced with language", "```text\nfeat: add login\n\nBody.\n```", "feat: add login\n\nBody."},
+		{"fence after a preamble", "Here is the message:\n\n```git-commit\nfix: typo\n```", "fix: typo"},
+		{"fence in the body kept", "feat: add config loader\n\nExample usage:\n\n```toml\nmodel = \"x\"\n```\n\nRefs: X", "feat: add config loader\n\nExample usage:\n\n```toml\nmodel = \"x\"\n```\n\nRefs: X"},
+		{"fence ending the body kept", "feat: add config loader\n\n```toml\nmodel = \"x\"\n```", "feat: add config loader\n\n```toml\nmodel = \"x\"\n```"},
+		{"preamble", "Here is your commit message:\nfeat: add login", "feat: add login"},
+		{"preamble and blank line", "Sure! Here's a commit message for these changes:\n\nfix: typo", "fix: typo"},
+		{"label line", "Commit message:\nfix: typo", "fix: typo"},
+		{"inline label", "Suggested commit message: fix: typo", "fix: typo"},
+		{"double quotes", `"fix: typo"`, "fix: typo"},
+		{"backticks", "`fix: typo`", "fix: typo"},
+		{"curly quotes", "“fix: typo”", "fix: typo"},
+		{"quoted word kept", `fix: handle "quoted" names`, `fix: handle "quoted" names`},
+		{"quotes around parts kept", `"a" and "b"`, `"a" and "b"`},
+		{"subject ending in a colon kept", "docs: explain the following:\n\n- a\n- b", "docs: explain the following:\n\n- a\n- b"},
+		{"subject starting with ok kept", "Okay button no longer closes the dialog", "Okay button no longer closes the dialog"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cleanResponse(tt.response); got != tt.want {
+				t.Errorf("cleanResponse(%q) = %q, want %q", tt.response, got, tt.want)
+			}
+		})
+	}
+}
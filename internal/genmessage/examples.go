@@ -0,0 +1,78 @@
+package genmessage
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/hamzabow/co/internal/git"
+)
+
+const (
+	// exampleCandidates is the number of past commits examples are chosen from
+	exampleCandidates = 100
+	// maxExamplePaths bounds the pathspec used to find commits touching the staged files
+	maxExamplePaths = 100
+	// maxExampleLines trims long commit bodies, which say little more about style
+	maxExampleLines = 15
+	// minExampleLength skips messages too short to show a convention
+	minExampleLength = 10
+)
+
+// unrepresentativeMessage matches commits whose message doesn't follow the
+// repository's conventions: fixups, reverts, merges and work in progress
+var unrepresentativeMessage = regexp.MustCompile(`(?i)^(fixup!|squash!|amend!|revert\b|merge\b|wip\b)`)
+
+// exampleType is the leading word of a subject, e.g. "feat(api)" or an emoji,
+// used to pick examples showing different kinds of changes
+var exampleType = regexp.MustCompile(`^[^\s:]+`)
+
+// commitExamples samples n past commit messages from the default branch to
+// show the model the repository's conventions. Commits touching the staged
+// files are preferred, and examples of different types are picked first.
+func commitExamples(n int) []string {
+	branch := git.DefaultBranch()
+
+	var candidates []string
+	if files, err := git.StagedFiles(); err == nil && len(files) > 0 {
+		messages, _ := git.CommitMessages(branch, exampleCandidates, files[:min(len(files), maxExamplePaths)])
+		candidates = append(candidates, messages...)
+	}
+	// Fill up from the whole history when few commits touched these files
+	messages, _ := git.CommitMessages(branch, exampleCandidates, nil)
+	candidates = append(candidates, messages...)
+
+	var examples []string
+	seen := make(map[string]bool)
+	types := make(map[string]bool)
+
+	// The first pass takes one example per type, the second fills the rest
+	for pass := 0; pass < 2 && len(examples) < n; pass++ {
+		for _, message := range candidates {
+			if len(examples) == n {
+				break
+			}
+			if seen[message] || len(message) < minExampleLength || unrepresentativeMessage.MatchString(message) {
+				continue
+			}
+			kind := exampleType.FindString(message)
+			if pass == 0 && types[kind] {
+				continue
+			}
+
+			seen[message] = true
+			types[kind] = true
+			examples = append(examples, trimExample(message))
+		}
+	}
+
+	return examples
+}
+
+// trimExample shortens long commit messages
+func trimExample(message string) string {
+	lines := strings.Split(message, "\n")
+	if len(lines) <= maxExampleLines {
+		return message
+	}
+	return strings.Join(lines[:maxExampleLines], "\n") + "\n[...]"
+}
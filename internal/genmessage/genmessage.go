@@ -5,56 +5,201 @@ import (
 	"errors"
 	"fmt"
 	"os/exec"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/hamzabow/co/internal/config"
 	"github.com/hamzabow/co/internal/confirmation"
+	"github.com/hamzabow/co/internal/git"
+	"github.com/hamzabow/co/internal/gitdiff"
+	"github.com/hamzabow/co/internal/lint"
 	"github.com/hamzabow/co/internal/prompts"
-	"github.com/openai/openai-go"
-	"github.com/openai/openai-go/option"
+	"github.com/hamzabow/co/internal/provider"
+	"github.com/hamzabow/co/internal/secrets"
 
-	spinner "github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	_ "github.com/joho/godotenv/autoload"
 )
 
 var (
-	ErrMissingAPIKey     = errors.New("missing OPENAI_API_KEY environment variable")
-	ErrFailedToGetDiffs  = errors.New("failed to get git diffs")
-	ErrNoChangesInRepo   = errors.New("no staged changes detected in the repository; use 'git add' to stage changes")
-	ErrNoChangesAtAll    = errors.New("no changes detected in the repository; make some changes before generating a commit message")
-	ErrOpenAIFetchFailed = errors.New("failed to fetch response from OpenAI API")
+	ErrFailedToGetDiffs    = errors.New("failed to get git diffs")
+	ErrNoChangesInRepo     = errors.New("no staged changes detected in the repository; use 'git add' to stage changes")
+	ErrNoChangesAtAll      = errors.New("no changes detected in the repository; make some changes before generating a commit message")
+	ErrProviderFetchFailed = errors.New("failed to fetch response from AI provider")
+	ErrGenerationCancelled = errors.New("commit message generation cancelled")
+	ErrGenerationTimedOut  = errors.New("commit message generation timed out")
+	ErrSecretsDetected     = errors.New("possible secrets detected in the staged changes")
+	ErrUnknownSecretsMode  = errors.New("unknown secrets mode")
 )
 
-// Define a custom model that embeds spinner.Model and implements tea.Model
-type customSpinnerModel struct {
-	spinner.Model
-	message string
+// Options controls how a commit message is generated
+type Options struct {
+	// Provider is the AI backend used for generation
+	Provider provider.Provider
+	// Prompts holds the available prompt templates
+	Prompts *prompts.Registry
+	// Format is the name of the prompt template to use
+	Format string
+	// Language is the natural language the message should be written in (optional)
+	Language string
+	// Scopes lists the allowed commit scopes (optional)
+	Scopes []string
+	// MaxDiffSize caps the diff sent to the model to this many bytes (0 means unlimited)
+	MaxDiffSize int
+	// ContextWindow is the model's context window in tokens (0 looks it up from the model name)
+	ContextWindow int
+	// Summarize selects when large diffs are summarized in parts (config.SummarizeNever, config.SummarizeAuto or config.SummarizeAlways)
+	Summarize string
+	// SummarizeBy groups the files summarized together by "file" or "dir"
+	SummarizeBy string
+	// SummarizeWorkers bounds the number of concurrent summary requests
+	SummarizeWorkers int
+	// Exclude matches files whose diff isn't sent to the model (nil excludes nothing)
+	Exclude *gitdiff.Matcher
+	// ExcludeBinary leaves binary files out of the diff
+	ExcludeBinary bool
+	// Secrets selects how secrets in the diff are handled (secrets.ModeRedact, ModeBlock or ModeOff)
+	Secrets string
+	// KnownSecrets are values that are always redacted, such as stored API keys
+	KnownSecrets []string
+	// AutoStage stages all changes without asking when nothing is staged
+	AutoStage bool
+	// Stream previews the response while it is being generated
+	Stream bool
+	// Timeout bounds the provider request (0 means no limit beyond the HTTP client's)
+	Timeout time.Duration
+	// Context selects the repository context given to the model besides the diff
+	Context ContextOptions
+	// Ticket adds ticket references from the branch name to the message
+	Ticket TicketOptions
+	// Lint checks the generated message (nil disables regeneration)
+	Lint *lint.Config
+	// MaxAttempts bounds the number of requests made to get a message that passes Lint
+	MaxAttempts int
 }
 
-// Implement the Init method for the custom model
-func (m customSpinnerModel) Init() tea.Cmd {
-	return m.Model.Tick
+// ContextOptions toggles the sources of repository context
+type ContextOptions struct {
+	// Branch includes the current branch name
+	Branch bool
+	// Stat includes the output of git diff --staged --stat
+	Stat bool
+	// Files includes the staged, renamed and deleted file lists
+	Files bool
+	// RecentCommits includes the subjects of this many recent commits (0 disables it)
+	RecentCommits int
+	// Examples includes this many past commit messages as examples of the house style (0 disables it)
+	Examples int
 }
 
-// Implement the Update method for the custom model
-func (m customSpinnerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	newModel, cmd := m.Model.Update(msg)
-	m.Model = newModel
-	return m, cmd
+// GenerateCommitMessage asks the configured provider for a commit message describing the staged changes.
+// Cancelling ctx, or pressing Esc or Ctrl+C while generating, returns ErrGenerationCancelled.
+func GenerateCommitMessage(ctx context.Context, opts Options) (string, error) {
+	g, err := NewGenerator(ctx, opts)
+	if err != nil {
+		return "", err
+	}
+
+	messages, err := g.Generate(ctx, 1)
+	if err != nil {
+		return "", err
+	}
+	return messages[0], nil
+}
+
+// Generator generates commit messages for the staged changes. The prompt is
+// prepared once, so that more messages can be asked for without redoing that work.
+type Generator struct {
+	opts   Options
+	ticket *ticketInjector
+	prompt string
+}
+
+// NewGenerator validates the options and prepares the prompt from the staged
+// changes, offering to stage all changes when nothing is staged. Summarizing a
+// large diff may take a while and can be cancelled like Generate.
+func NewGenerator(ctx context.Context, opts Options) (*Generator, error) {
+	if err := validateSummarize(opts); err != nil {
+		return nil, err
+	}
+	if !slices.Contains(secrets.Modes(), opts.Secrets) {
+		return nil, fmt.Errorf("%w: %q (available: %s)", ErrUnknownSecretsMode, opts.Secrets, strings.Join(secrets.Modes(), ", "))
+	}
+	ticket, err := newTicketInjector(opts.Ticket)
+	if err != nil {
+		return nil, err
+	}
+
+	prompt, err := preparePrompt(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Generator{opts: opts, ticket: ticket, prompt: prompt}, nil
+}
+
+// Generate asks for n distinct messages. A single message is previewed while it
+// streams in, several are requested concurrently. Cancelling ctx, or pressing
+// Esc or Ctrl+C while generating, returns ErrGenerationCancelled.
+func (g *Generator) Generate(ctx context.Context, n int) ([]string, error) {
+	messages, err := generate(ctx, g.opts, candidatesTask(g.opts, g.prompt, max(n, 1)))
+	if err != nil {
+		return nil, err
+	}
+
+	// Ticket references are added here rather than trusting the model to copy them
+	for i, message := range messages {
+		if messages[i], err = applyTicket(message, g.ticket); err != nil {
+			return nil, err
+		}
+	}
+	return messages, nil
 }
 
-// Implement the View method for the custom model
-func (m customSpinnerModel) View() string {
-	// Apply the style to both the spinner and the message text
-	return m.Model.View() + " " + m.message
+// Regenerate asks for a new message without showing the spinner, for use from
+// views that show their own progress
+func (g *Generator) Regenerate(ctx context.Context) (string, error) {
+	opts := g.quietOptions()
+	return g.quietly(ctx, promptTask(opts, g.prompt))
 }
 
-func GenerateCommitMessage(key string) (string, error) {
+// Refine asks for a revision of message following the user's instruction,
+// e.g. "shorter" or "mention the migration", without showing the spinner
+func (g *Generator) Refine(ctx context.Context, message, instruction string) (string, error) {
+	opts := g.quietOptions()
+	return g.quietly(ctx, promptTask(opts, refinePrompt(g.prompt, message, instruction)))
+}
 
-	client := openai.NewClient(option.WithAPIKey(key))
+// quietOptions returns the options for requests made without the spinner,
+// which is the only view showing streamed responses
+func (g *Generator) quietOptions() Options {
+	opts := g.opts
+	opts.Stream = false
+	return opts
+}
 
+// quietly runs the task without the spinner and adds the ticket reference
+func (g *Generator) quietly(ctx context.Context, run task[string]) (string, error) {
+	if g.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.opts.Timeout)
+		defer cancel()
+	}
+
+	message, err := run(ctx, func(tea.Msg) {})
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return "", fmt.Errorf("%w after %s", ErrGenerationTimedOut, g.opts.Timeout)
+	case ctx.Err() != nil:
+		return "", ErrGenerationCancelled
+	case err != nil:
+		return "", fmt.Errorf("%w: %w", ErrProviderFetchFailed, err)
+	}
+	return applyTicket(message, g.ticket)
+}
+
+// preparePrompt renders the prompt describing the staged changes
+func preparePrompt(ctx context.Context, opts Options) (string, error) {
 	diff, err := getGitDiff()
 
 	if err != nil {
@@ -72,10 +217,13 @@ func GenerateCommitMessage(key string) (string, error) {
 			return "", ErrNoChangesAtAll
 		}
 
-		// Use the modern Bubble Tea confirmation component
-		confirmed, err := confirmation.Confirm("No staged changes detected. Would you like to stage all changes?", true)
-		if err != nil {
-			return "", err
+		confirmed := opts.AutoStage
+		if !confirmed {
+			// Use the modern Bubble Tea confirmation component
+			confirmed, err = confirmation.Confirm("No staged changes detected. Would you like to stage all changes?", true)
+			if err != nil {
+				return "", err
+			}
 		}
 
 		if confirmed {
@@ -100,56 +248,339 @@ func GenerateCommitMessage(key string) (string, error) {
 		}
 	}
 
-	// prompt := fmt.Sprintf(prompts.GitmojiPrompt, diff)
-	prompt := fmt.Sprintf(prompts.LongConventionalCommitsPrompt, diff)
+	data := promptData(diff, opts)
 
-	// Create a new custom spinner model
-	s := customSpinnerModel{
-		Model: spinner.New(),
-		message: lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#FAFAFA")).
-			Background(lipgloss.Color("#7D56F4")).
-			Bold(true).
-			PaddingLeft(2).
-			PaddingRight(2).
-			Render(" Generating Commit Message "),
+	// Measure the prompt without the diff to know how much room the diff has
+	withoutDiff := data
+	withoutDiff.Diff = ""
+	overhead, err := opts.Prompts.Render(opts.Format, withoutDiff)
+	if err != nil {
+		return "", err
 	}
-	s.Spinner = spinner.Dot
-	s.Style = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#7D56F4")).
-		PaddingLeft(2).
-		PaddingTop(1)
 
-	// Start the spinner in a separate goroutine
-	p := tea.NewProgram(s)
-	go func() {
-		_, err := p.Run()
+	// Excluded files are only listed, so the message can still mention them
+	parsed := gitdiff.Parse(diff)
+	files, excluded := gitdiff.Exclude(parsed, opts.Exclude, opts.ExcludeBinary)
+	excludedNote := gitdiff.ExcludedNote(excluded)
+
+	// Nothing leaves the machine before secrets are dealt with
+	if opts.Secrets != secrets.ModeOff {
+		scanner := secrets.NewScanner(opts.KnownSecrets)
+		var findings []secrets.Finding
+		if len(parsed) > 0 {
+			files, findings = scanner.Redact(files)
+		} else {
+			// Output that couldn't be parsed as a diff is sent as is, so all of it is scanned
+			data.Diff, findings = scanner.RedactText("staged diff", data.Diff)
+		}
+		if len(findings) > 0 && opts.Secrets == secrets.ModeBlock {
+			return "", fmt.Errorf("%w:\n%s", ErrSecretsDetected, listFindings(findings, len(findings)))
+		}
+		if len(findings) > 0 {
+			fmt.Println(noticeStyle.Render("Redacted possible secrets before sending the diff:\n" + listFindings(findings, maxListedFiles)))
+		}
+	}
+
+	budget := diffBudget(opts, gitdiff.EstimateTokens(overhead)+gitdiff.EstimateTokens(excludedNote))
+	fitted := gitdiff.Fit(files, budget)
+
+	if len(files) > 0 && shouldSummarize(opts.Summarize, fitted.OriginalTokens, budget) {
+		chunkBudget, err := chunkSummaryBudget(opts)
 		if err != nil {
-			fmt.Println("Error starting spinner:", err)
+			return "", err
 		}
-	}()
+		chunks := gitdiff.Chunk(files, gitdiff.Grouping(opts.SummarizeBy), chunkBudget)
+		return generate(ctx, opts, summarizeTask(opts, data, files, chunks, chunkBudget, budget, excludedNote))
+	}
+
+	// Output that couldn't be parsed as a diff is passed through, only redacted
+	if len(parsed) > 0 {
+		if fitted.Trimmed() {
+			fmt.Println(noticeStyle.Render(trimNotice(fitted, opts.Summarize)))
+		}
+		data.Diff = fitted.Diff + excludedNote
+	}
+
+	return opts.Prompts.Render(opts.Format, data)
+}
+
+// responseTokenReserve keeps room in the context window for the generated message
+const responseTokenReserve = 1024
+
+// minDiffBudget keeps a usable amount of diff when the prompt alone nearly fills the window
+const minDiffBudget = 512
+
+// noticeStyle renders warnings about the diff sent to the model
+var noticeStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("#FFB86C")).
+	PaddingLeft(1)
+
+// diffBudget returns how many tokens of diff fit next to a prompt of overhead tokens
+func diffBudget(opts Options, overhead int) int {
+	window := opts.ContextWindow
+	if window <= 0 {
+		window = gitdiff.ContextWindow(opts.Provider.Model())
+	}
+
+	budget := max(window-overhead-responseTokenReserve, minDiffBudget)
+	if opts.MaxDiffSize > 0 {
+		budget = min(budget, gitdiff.TokensForBytes(opts.MaxDiffSize))
+	}
+	return budget
+}
 
-	chatCompletion, err := client.Chat.Completions.New(context.TODO(), openai.ChatCompletionNewParams{
-		Messages: openai.F([]openai.ChatCompletionMessageParamUnion{
-			openai.UserMessage(prompt),
-		}),
-		Model: openai.F(openai.ChatModelGPT4o),
-	})
+// maxListedFiles is the number of trimmed files named in the notice
+const maxListedFiles = 5
 
-	// Ensure the spinner stops completely
-	p.Quit()
-	// Give a small pause to allow the spinner goroutine to clean up
-	time.Sleep(100 * time.Millisecond)
+// trimNotice tells the user which parts of the diff the model won't see
+func trimNotice(fitted gitdiff.Result, summarize string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "The staged diff (~%d tokens) is too large for the model, sending ~%d tokens.",
+		fitted.OriginalTokens, fitted.Tokens)
+	if len(fitted.Truncated) > 0 {
+		b.WriteString("\nPartially included: " + listFiles(fitted.Truncated))
+	}
+	if len(fitted.Omitted) > 0 {
+		b.WriteString("\nOmitted (line counts only): " + listFiles(fitted.Omitted))
+	}
+	if summarize == config.SummarizeNever {
+		b.WriteString("\nSet summarize to auto to summarize the whole diff in parts instead.")
+	}
+	return b.String()
+}
+
+// listFiles joins the first few file paths, counting the rest
+func listFiles(files []gitdiff.File) string {
+	paths := make([]string, 0, maxListedFiles)
+	for _, f := range files[:min(len(files), maxListedFiles)] {
+		paths = append(paths, f.Path)
+	}
+	list := strings.Join(paths, ", ")
+	if len(files) > maxListedFiles {
+		list += fmt.Sprintf(" and %d more", len(files)-maxListedFiles)
+	}
+	return list
+}
 
+// listFindings lists up to limit findings, one per line, counting the rest
+func listFindings(findings []secrets.Finding, limit int) string {
+	lines := make([]string, 0, limit+1)
+	for _, f := range findings[:min(len(findings), limit)] {
+		lines = append(lines, "  "+f.String())
+	}
+	if len(findings) > limit {
+		lines = append(lines, fmt.Sprintf("  and %d more", len(findings)-limit))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// generateResult is the outcome of a task
+type generateResult[T any] struct {
+	value T
+	err   error
+}
+
+// task performs provider requests, reporting progress to the spinner view with send
+type task[T any] func(ctx context.Context, send func(tea.Msg)) (T, error)
+
+// promptTask sends the prompt and cleans up the response. While the message
+// breaks error level lint rules, the model is asked to fix it, up to
+// MaxAttempts requests in total; the last message is returned either way.
+func promptTask(opts Options, prompt string) task[string] {
+	return func(ctx context.Context, send func(tea.Msg)) (string, error) {
+		message, err := request(ctx, opts, prompt, send)
+		if err != nil || opts.Lint == nil {
+			return message, err
+		}
+
+		for attempt := 2; attempt <= opts.MaxAttempts; attempt++ {
+			violations := lint.Lint(message, *opts.Lint)
+			if !lint.HasErrors(violations) {
+				break
+			}
+
+			send(attemptMsg{attempt: attempt, total: opts.MaxAttempts})
+			message, err = request(ctx, opts, revisionPrompt(prompt, message, violations), send)
+			if err != nil {
+				return "", err
+			}
+		}
+		return message, nil
+	}
+}
+
+// candidatesTask asks for n distinct messages. Several messages are requested
+// concurrently without a preview, which can't show them all.
+func candidatesTask(opts Options, prompt string, n int) task[[]string] {
+	return func(ctx context.Context, send func(tea.Msg)) ([]string, error) {
+		if n == 1 {
+			message, err := promptTask(opts, prompt)(ctx, send)
+			return []string{message}, err
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		opts.Stream = false
+		results := make(chan generateResult[string], n)
+		for range n {
+			go func() {
+				message, err := promptTask(opts, prompt)(ctx, func(tea.Msg) {})
+				results <- generateResult[string]{value: message, err: err}
+			}()
+		}
+
+		var messages []string
+		send(progressMsg{label: "Generating Candidates", total: n})
+		for i := range n {
+			result := <-results
+			if result.err != nil {
+				// Stops the other requests
+				return nil, result.err
+			}
+			// Models sometimes answer the same prompt identically
+			if !slices.Contains(messages, result.value) {
+				messages = append(messages, result.value)
+			}
+			send(progressMsg{label: "Generating Candidates", done: i + 1, total: n})
+		}
+		return messages, nil
+	}
+}
+
+// request sends a single prompt, previewing the response if streaming is enabled
+func request(ctx context.Context, opts Options, prompt string, send func(tea.Msg)) (string, error) {
+	req := provider.Request{Prompt: prompt}
+	if opts.Stream {
+		req.OnDelta = func(delta string) {
+			send(deltaMsg(delta))
+		}
+	}
+
+	response, err := opts.Provider.Generate(ctx, req)
 	if err != nil {
-		return "", ErrOpenAIFetchFailed
+		return "", err
+	}
+	return cleanResponse(response), nil
+}
+
+// revisionPrompt asks the model to fix the lint violations of its previous answer
+func revisionPrompt(prompt, message string, violations []lint.Violation) string {
+	var b strings.Builder
+	b.WriteString(prompt)
+	b.WriteString("\n\n---\n\nYour previous answer was:\n```\n" + message + "\n```\n\n")
+	b.WriteString("It breaks the following rules of this repository (line numbers refer to the message):\n")
+	for _, v := range violations {
+		fmt.Fprintf(&b, "- line %d: %s\n", v.Line, v.Message)
 	}
-	response := chatCompletion.Choices[0].Message.Content
-	return response, nil
+	b.WriteString("\nWrite a corrected commit message that follows these rules. Return only the commit message, no extra text, and don't wrap it with code blocks.")
+	return b.String()
+}
+
+// refinePrompt asks the model to revise the current message following the user's instruction
+func refinePrompt(prompt, message, instruction string) string {
+	var b strings.Builder
+	b.WriteString(prompt)
+	b.WriteString("\n\n---\n\nThe current commit message is:\n```\n" + message + "\n```\n\n")
+	b.WriteString("Revise it following this instruction: " + instruction + "\n")
+	b.WriteString("\nKeep what the instruction doesn't ask to change. Return only the commit message, no extra text, and don't wrap it with code blocks.")
+	return b.String()
+}
+
+// generate runs the task while the spinner view runs in the foreground, so
+// that its key bindings can cancel the requests
+func generate[T any](ctx context.Context, opts Options, run task[T]) (T, error) {
+	var zero T
+	genCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if opts.Timeout > 0 {
+		genCtx, cancel = context.WithTimeout(genCtx, opts.Timeout)
+		defer cancel()
+	}
+
+	spinnerModel := newSpinnerModel(cancel)
+	sp := tea.NewProgram(spinnerModel, tea.WithContext(ctx))
+
+	results := make(chan generateResult[T], 1)
+	go func() {
+		value, err := run(genCtx, sp.Send)
+		results <- generateResult[T]{value: value, err: err}
+		// Stops the spinner; a no-op if it has already exited
+		sp.Send(doneMsg{})
+	}()
+
+	if _, err := sp.Run(); err != nil && !errors.Is(err, tea.ErrProgramKilled) {
+		// Without a working terminal, keep waiting for the request without a spinner
+		fmt.Println("Error starting spinner:", err)
+	}
+
+	if spinnerModel.cancelled || ctx.Err() != nil {
+		return zero, ErrGenerationCancelled
+	}
+
+	result := <-results
+	if errors.Is(genCtx.Err(), context.DeadlineExceeded) {
+		return zero, fmt.Errorf("%w after %s", ErrGenerationTimedOut, opts.Timeout)
+	}
+	if result.err != nil {
+		return zero, fmt.Errorf("%w: %w", ErrProviderFetchFailed, result.err)
+	}
+	return result.value, nil
+}
+
+// maxStatFiles is the number of files listed in the diff stat
+const maxStatFiles = 50
+
+// promptData gathers the template variables describing the staged changes.
+// Context other than the diff is best effort: failures leave the field empty.
+func promptData(diff string, opts Options) prompts.Data {
+	data := prompts.Data{
+		Diff:     diff,
+		Scopes:   opts.Scopes,
+		Language: opts.Language,
+	}
+
+	if opts.Context.Branch {
+		if branch, err := git.CurrentBranch(); err == nil {
+			data.Branch = branch
+		}
+	}
+	if opts.Context.Stat {
+		if stat, err := git.StagedStat(maxStatFiles); err == nil {
+			data.Stat = stat
+		}
+	}
+	if opts.Context.Files {
+		if files, err := git.StagedFiles(); err == nil {
+			data.Files = files
+		}
+		if changes, err := git.StagedChanges(); err == nil {
+			for _, change := range changes {
+				switch change.Status {
+				case "R":
+					data.Renamed = append(data.Renamed, prompts.Rename{From: change.OldPath, To: change.Path})
+				case "D":
+					data.Deleted = append(data.Deleted, change.Path)
+				}
+			}
+		}
+	}
+	if opts.Context.RecentCommits > 0 {
+		if commits, err := git.RecentCommitSubjects(opts.Context.RecentCommits); err == nil {
+			data.RecentCommits = commits
+		}
+	}
+	if opts.Context.Examples > 0 {
+		data.Examples = commitExamples(opts.Context.Examples)
+	}
+
+	return data
 }
 
 func getGitDiff() (string, error) {
-	cmd := exec.Command("git", "diff", "--staged")
+	// External diff tools, colors and other path prefixes (diff.noprefix,
+	// diff.mnemonicPrefix) would make the output unparseable
+	cmd := exec.Command("git", "diff", "--staged", "--no-ext-diff", "--no-color", "--src-prefix=a/", "--dst-prefix=b/")
 	output, err := cmd.CombinedOutput()
 	return string(output), err
 }
@@ -0,0 +1,162 @@
+package genmessage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	spinner "github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// previewLines is the number of trailing lines of a streamed response shown while generating
+const previewLines = 12
+
+var (
+	// Label style with white text on purple background
+	labelStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FAFAFA")).
+			Background(lipgloss.Color("#7D56F4")).
+			Bold(true).
+			PaddingLeft(2).
+			PaddingRight(2)
+
+	// Preview box for streamed text
+	previewStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("#7D56F4")).
+			Foreground(lipgloss.Color("#FAFAFA")).
+			PaddingLeft(1).
+			PaddingRight(1).
+			MarginLeft(2)
+
+	// Help text style
+	spinnerHelpStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#626262")).
+				PaddingLeft(2)
+)
+
+// deltaMsg carries a chunk of streamed text to the spinner view
+type deltaMsg string
+
+// doneMsg tells the spinner view that generation finished
+type doneMsg struct{}
+
+// attemptMsg tells the spinner view that a message is being regenerated
+// because the previous one broke lint rules
+type attemptMsg struct {
+	attempt, total int
+}
+
+// progressMsg reports how many of several concurrent requests are done
+type progressMsg struct {
+	label       string
+	done, total int
+}
+
+// Define a custom model that embeds spinner.Model and implements tea.Model
+type customSpinnerModel struct {
+	spinner.Model
+	message  string
+	preview  strings.Builder
+	progress progressMsg
+	cancel   context.CancelFunc
+	done     bool
+	width    int
+
+	// cancelled is set when the user stopped generation with Esc or Ctrl+C
+	cancelled bool
+}
+
+func newSpinnerModel(cancel context.CancelFunc) *customSpinnerModel {
+	s := &customSpinnerModel{
+		Model:   spinner.New(),
+		message: labelStyle.Render(" Generating Commit Message "),
+		cancel:  cancel,
+		width:   80,
+	}
+	s.Spinner = spinner.Dot
+	s.Style = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#7D56F4")).
+		PaddingLeft(2).
+		PaddingTop(1)
+	return s
+}
+
+// Implement the Init method for the custom model
+func (m *customSpinnerModel) Init() tea.Cmd {
+	return m.Model.Tick
+}
+
+// Implement the Update method for the custom model
+func (m *customSpinnerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		return m, nil
+
+	case tea.KeyMsg:
+		// Esc stops a response that is going off the rails, Ctrl+C cancels as usual
+		if msg.Type == tea.KeyEsc || msg.Type == tea.KeyCtrlC {
+			m.cancel()
+			m.cancelled = true
+			m.done = true
+			return m, tea.Quit
+		}
+		return m, nil
+
+	case deltaMsg:
+		m.preview.WriteString(string(msg))
+		return m, nil
+
+	case progressMsg:
+		m.progress = msg
+		return m, nil
+
+	case attemptMsg:
+		// The preview shows the new attempt from scratch
+		m.message = labelStyle.Render(fmt.Sprintf(" Fixing Commit Message (attempt %d/%d) ", msg.attempt, msg.total))
+		m.preview.Reset()
+		return m, nil
+
+	case doneMsg:
+		// Clear the view before quitting so the preview doesn't linger
+		m.done = true
+		return m, tea.Quit
+	}
+
+	newModel, cmd := m.Model.Update(msg)
+	m.Model = newModel
+	return m, cmd
+}
+
+// Implement the View method for the custom model
+func (m *customSpinnerModel) View() string {
+	if m.done {
+		return ""
+	}
+
+	// Apply the style to both the spinner and the message text
+	message := m.message
+	if m.progress.done < m.progress.total {
+		message = labelStyle.Render(fmt.Sprintf(" %s %d/%d ", m.progress.label, m.progress.done, m.progress.total))
+	}
+	view := m.Model.View() + " " + message + "\n"
+
+	if m.preview.Len() > 0 {
+		// Only show the end of the text so the view doesn't outgrow the terminal
+		lines := strings.Split(strings.TrimRight(m.preview.String(), "\n"), "\n")
+		if len(lines) > previewLines {
+			lines = lines[len(lines)-previewLines:]
+		}
+
+		width := m.width - 6 // margin, border and padding
+		if width < 20 {
+			width = 20
+		}
+		view += previewStyle.Width(width).Render(strings.Join(lines, "\n")) + "\n"
+	}
+
+	return view + spinnerHelpStyle.Render("Esc or Ctrl+C to cancel")
+}
@@ -0,0 +1,164 @@
+package genmessage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/hamzabow/co/internal/config"
+	"github.com/hamzabow/co/internal/gitdiff"
+	"github.com/hamzabow/co/internal/prompts"
+	"github.com/hamzabow/co/internal/provider"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var (
+	ErrUnknownSummarizeMode     = errors.New("unknown summarize mode")
+	ErrUnknownSummarizeGrouping = errors.New("unknown summarize grouping")
+)
+
+// validateSummarize checks the summarize options before any work is done
+func validateSummarize(opts Options) error {
+	switch opts.Summarize {
+	case config.SummarizeNever, config.SummarizeAuto, config.SummarizeAlways:
+	default:
+		return fmt.Errorf("%w: %q (available: %s, %s, %s)", ErrUnknownSummarizeMode,
+			opts.Summarize, config.SummarizeNever, config.SummarizeAuto, config.SummarizeAlways)
+	}
+
+	for _, by := range gitdiff.Groupings() {
+		if gitdiff.Grouping(opts.SummarizeBy) == by {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q (available: %s, %s)", ErrUnknownSummarizeGrouping,
+		opts.SummarizeBy, gitdiff.ByFile, gitdiff.ByDir)
+}
+
+// shouldSummarize reports whether a diff of the given size is summarized in parts
+func shouldSummarize(mode string, tokens, budget int) bool {
+	return mode == config.SummarizeAlways || (mode == config.SummarizeAuto && tokens > budget)
+}
+
+// chunkSummaryBudget returns how many tokens of diff fit in a summary request
+func chunkSummaryBudget(opts Options) (int, error) {
+	overhead, err := prompts.RenderChunkSummary("")
+	if err != nil {
+		return 0, err
+	}
+	return diffBudget(opts, gitdiff.EstimateTokens(overhead)), nil
+}
+
+// summarizeTask summarizes the chunks concurrently, then renders the commit
+// message prompt with the summaries in place of the diff, followed by the note
+func summarizeTask(opts Options, data prompts.Data, files []gitdiff.File, chunks [][]gitdiff.File, chunkBudget, budget int, note string) task[string] {
+	return func(ctx context.Context, send func(tea.Msg)) (string, error) {
+		summaries, err := summarizeChunks(ctx, opts, chunks, chunkBudget, send)
+		if err != nil {
+			return "", err
+		}
+
+		data.Diff = gitdiff.TruncateTokens(summarizedDiff(files, chunks, summaries), budget) + note
+		return opts.Prompts.Render(opts.Format, data)
+	}
+}
+
+// summarizeChunks asks for a summary of every chunk using a bounded pool of
+// workers. The first failure cancels the remaining requests.
+func summarizeChunks(ctx context.Context, opts Options, chunks [][]gitdiff.File, budget int, send func(tea.Msg)) ([]string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	summaries := make([]string, len(chunks))
+	jobs := make(chan int)
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		done     int
+	)
+
+	send(progressMsg{label: "Summarizing Changes", total: len(chunks)})
+
+	workers := min(max(opts.SummarizeWorkers, 1), len(chunks))
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				summary, err := summarizeChunk(ctx, opts, chunks[i], budget)
+
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = err
+					cancel()
+				} else if err == nil {
+					summaries[i] = summary
+					done++
+					send(progressMsg{label: "Summarizing Changes", done: done, total: len(chunks)})
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for i := range chunks {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
+
+// summarizeChunk asks for a summary of a single chunk, trimmed to fit the budget
+func summarizeChunk(ctx context.Context, opts Options, chunk []gitdiff.File, budget int) (string, error) {
+	prompt, err := prompts.RenderChunkSummary(gitdiff.Fit(chunk, budget).Diff)
+	if err != nil {
+		return "", err
+	}
+
+	summary, err := opts.Provider.Generate(ctx, provider.Request{Prompt: prompt})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(summary), nil
+}
+
+// summarizedDiff stands in for the diff in the commit message prompt
+func summarizedDiff(files []gitdiff.File, chunks [][]gitdiff.File, summaries []string) string {
+	added, deleted := 0, 0
+	for _, f := range files {
+		added += f.Added
+		deleted += f.Deleted
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "[The staged changes span %d files (+%d -%d lines) and were summarized in parts instead of including the diff]\n",
+		len(files), added, deleted)
+
+	for i, chunk := range chunks {
+		paths := make([]string, len(chunk))
+		for j, f := range chunk {
+			paths[j] = fmt.Sprintf("%s (+%d -%d)", f.Path, f.Added, f.Deleted)
+		}
+		fmt.Fprintf(&b, "\nFiles: %s\n%s\n", strings.Join(paths, ", "), summaries[i])
+	}
+
+	return b.String()
+}
@@ -0,0 +1,207 @@
+package genmessage
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/hamzabow/co/internal/config"
+	"github.com/hamzabow/co/internal/git"
+)
+
+// defaultTicketTemplates are used when no template is configured
+var defaultTicketTemplates = map[string]string{
+	config.TicketPrefix: "{{.ID}} ",
+	config.TicketScope:  "{{.ID}}",
+	config.TicketFooter: "Refs: {{range $i, $id := .IDs}}{{if $i}}, {{end}}{{$id}}{{end}}",
+}
+
+// defaultFooterTemplate renders footers when the scope placement can't be used
+var defaultFooterTemplate = template.Must(template.New("ticket").Parse(defaultTicketTemplates[config.TicketFooter]))
+
+var (
+	ErrUnknownTicketPlacement = errors.New("unknown ticket placement")
+	ErrInvalidTicketPattern   = errors.New("invalid ticket pattern")
+	ErrInvalidTicketTemplate  = errors.New("invalid ticket template")
+)
+
+// TicketOptions configures how ticket references are taken from the branch
+// name and added to the generated message
+type TicketOptions struct {
+	// Pattern matches ticket IDs in the branch name; its first group, if any, is the ID
+	Pattern string
+	// Placement is config.TicketNone, config.TicketPrefix, config.TicketScope or config.TicketFooter
+	Placement string
+	// Template renders the inserted text from .ID, .IDs, .Branch and, for scopes, .Scope
+	// (empty uses the placement's default)
+	Template string
+}
+
+// ticketData holds the variables available to ticket templates
+type ticketData struct {
+	// ID is the first ticket ID found in the branch name
+	ID string
+	// IDs lists every ticket ID found in the branch name
+	IDs []string
+	// Branch is the current branch name
+	Branch string
+	// Scope is the scope the model chose, for the scope placement
+	Scope string
+}
+
+// ticketInjector adds ticket references to messages
+type ticketInjector struct {
+	placement string
+	pattern   *regexp.Regexp
+	template  *template.Template
+}
+
+// newTicketInjector validates the options, returning nil when tickets are disabled
+func newTicketInjector(opts TicketOptions) (*ticketInjector, error) {
+	if !slices.Contains(config.TicketPlacements(), opts.Placement) {
+		return nil, fmt.Errorf("%w: %q (available: %s)", ErrUnknownTicketPlacement, opts.Placement, strings.Join(config.TicketPlacements(), ", "))
+	}
+	if opts.Placement == config.TicketNone || opts.Pattern == "" {
+		return nil, nil
+	}
+
+	pattern, err := regexp.Compile(opts.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTicketPattern, err)
+	}
+
+	text := opts.Template
+	if text == "" {
+		text = defaultTicketTemplates[opts.Placement]
+	}
+	tmpl, err := template.New("ticket").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTicketTemplate, err)
+	}
+
+	return &ticketInjector{placement: opts.Placement, pattern: pattern, template: tmpl}, nil
+}
+
+// ticketIDs finds the distinct ticket IDs in the branch name
+func (t *ticketInjector) ticketIDs(branch string) []string {
+	var ids []string
+	for _, m := range t.pattern.FindAllStringSubmatch(branch, -1) {
+		id := m[0]
+		if len(m) > 1 && m[1] != "" {
+			id = m[1]
+		}
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// conventionalSubject splits a Conventional Commits subject into its type,
+// optional scope, optional breaking change marker and description
+var conventionalSubject = regexp.MustCompile(`^(\w+)(?:\(([^)]*)\))?(!?): (.*)$`)
+
+// trailerLine matches git trailers and Conventional Commits footers
+var trailerLine = regexp.MustCompile(`^([\w-]+: |[\w-]+ #|BREAKING CHANGE: )`)
+
+// apply adds the references found in the branch name to the message. The
+// message is left as is when the branch has no ticket ID or the message
+// already mentions all of them, so that applying it twice changes nothing.
+func (t *ticketInjector) apply(message, branch string) (string, error) {
+	data := ticketData{IDs: t.ticketIDs(branch), Branch: branch}
+	if len(data.IDs) == 0 {
+		return message, nil
+	}
+	data.ID = data.IDs[0]
+
+	mentioned := true
+	for _, id := range data.IDs {
+		mentioned = mentioned && strings.Contains(message, id)
+	}
+	if mentioned {
+		return message, nil
+	}
+
+	subject, _, _ := strings.Cut(message, "\n")
+	rest := strings.TrimPrefix(message, subject)
+
+	switch t.placement {
+	case config.TicketPrefix:
+		text, err := render(t.template, data)
+		if err != nil {
+			return "", err
+		}
+		return text + subject + rest, nil
+
+	case config.TicketScope:
+		m := conventionalSubject.FindStringSubmatch(subject)
+		if m == nil {
+			// Without a type there is no scope to set, a footer is the least intrusive fallback
+			footer, err := render(defaultFooterTemplate, data)
+			if err != nil {
+				return "", err
+			}
+			return appendFooter(message, footer), nil
+		}
+		data.Scope = m[2]
+		scope, err := render(t.template, data)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s(%s)%s: %s", m[1], scope, m[3], m[4]) + rest, nil
+
+	case config.TicketFooter:
+		footer, err := render(t.template, data)
+		if err != nil {
+			return "", err
+		}
+		return appendFooter(message, footer), nil
+	}
+
+	return message, nil
+}
+
+// appendFooter adds a footer to the message, joining an existing block of
+// trailers rather than starting a new paragraph
+func appendFooter(message, footer string) string {
+	message = strings.TrimRight(message, "\n")
+	paragraphs := strings.Split(message, "\n\n")
+	if len(paragraphs) > 1 && allLinesMatch(paragraphs[len(paragraphs)-1], trailerLine) {
+		return message + "\n" + footer
+	}
+	return message + "\n\n" + footer
+}
+
+// render executes a ticket template
+func render(tmpl *template.Template, data ticketData) (string, error) {
+	var out strings.Builder
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidTicketTemplate, err)
+	}
+	return out.String(), nil
+}
+
+// allLinesMatch reports whether every line of the paragraph matches re
+func allLinesMatch(paragraph string, re *regexp.Regexp) bool {
+	for _, line := range strings.Split(paragraph, "\n") {
+		if !re.MatchString(line) {
+			return false
+		}
+	}
+	return true
+}
+
+// applyTicket adds ticket references from the current branch to the message
+func applyTicket(message string, ticket *ticketInjector) (string, error) {
+	if ticket == nil {
+		return message, nil
+	}
+	branch, err := git.CurrentBranch()
+	if err != nil {
+		return message, nil
+	}
+	return ticket.apply(message, branch)
+}
@@ -0,0 +1,160 @@
+package genmessage
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hamzabow/co/internal/config"
+)
+
+func TestTicketApply(t *testing.T) {
+	tests := []struct {
+		name      string
+		placement string
+		pattern   string
+		template  string
+		branch    string
+		message   string
+		want      string
+	}{
+		{
+			name: "prefix", placement: config.TicketPrefix,
+			branch: "feature/PROJ-123-login", message: "Add login form\n\nWith validation.",
+			want: "PROJ-123 Add login form\n\nWith validation.",
+		},
+		{
+			name: "scope", placement: config.TicketScope,
+			branch: "PROJ-123", message: "feat: add login",
+			want: "feat(PROJ-123): add login",
+		},
+		{
+			name: "scope replaces the model's scope", placement: config.TicketScope,
+			branch: "PROJ-123", message: "feat(auth)!: drop sessions\n\nBREAKING CHANGE: tokens only",
+			want: "feat(PROJ-123)!: drop sessions\n\nBREAKING CHANGE: tokens only",
+		},
+		{
+			name: "scope template keeps the model's scope", placement: config.TicketScope, template: "{{.Scope}}, {{.ID}}",
+			branch: "PROJ-123", message: "fix(api): handle nil",
+			want: "fix(api, PROJ-123): handle nil",
+		},
+		{
+			name: "scope falls back to a footer", placement: config.TicketScope,
+			branch: "PROJ-123", message: "Add login",
+			want: "Add login\n\nRefs: PROJ-123",
+		},
+		{
+			name: "footer lists every id", placement: config.TicketFooter,
+			branch: "PROJ-1-and-OPS-22-and-PROJ-1", message: "fix: race\n\nLock the map.\n",
+			want: "fix: race\n\nLock the map.\n\nRefs: PROJ-1, OPS-22",
+		},
+		{
+			name: "custom pattern with a group", placement: config.TicketPrefix, pattern: `issue-(\d+)`, template: "#{{.ID}}: ",
+			branch: "issue-42-crash", message: "Fix crash",
+			want: "#42: Fix crash",
+		},
+		{
+			name: "branch in template", placement: config.TicketFooter, template: "Branch: {{.Branch}}",
+			branch: "PROJ-9-x", message: "fix: x",
+			want: "fix: x\n\nBranch: PROJ-9-x",
+		},
+		{
+			name: "no ticket in branch", placement: config.TicketPrefix,
+			branch: "main", message: "feat: add login",
+			want: "feat: add login",
+		},
+		{
+			name: "already mentioned", placement: config.TicketFooter,
+			branch: "PROJ-123", message: "feat: add login\n\nRefs: PROJ-123",
+			want: "feat: add login\n\nRefs: PROJ-123",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pattern := tt.pattern
+			if pattern == "" {
+				pattern = config.DefaultTicketPattern
+			}
+			ticket, err := newTicketInjector(TicketOptions{Pattern: pattern, Placement: tt.placement, Template: tt.template})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := ticket.apply(tt.message, tt.branch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("apply() = %q, want %q", got, tt.want)
+			}
+
+			// Applying twice changes nothing
+			if again, _ := ticket.apply(got, tt.branch); again != got {
+				t.Errorf("second apply() = %q, want %q", again, got)
+			}
+		})
+	}
+}
+
+func TestAppendFooter(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{"subject only", "fix: x", "fix: x\n\nRefs: A-1"},
+		{"trailing newlines", "fix: x\n\n\n", "fix: x\n\nRefs: A-1"},
+		{"body", "fix: x\n\nSome body text.", "fix: x\n\nSome body text.\n\nRefs: A-1"},
+		{"joins trailers", "fix: x\n\nBody.\n\nSigned-off-by: A <a@b.c>", "fix: x\n\nBody.\n\nSigned-off-by: A <a@b.c>\nRefs: A-1"},
+		{"joins issue footers", "fix: x\n\nCloses #12", "fix: x\n\nCloses #12\nRefs: A-1"},
+		{"joins breaking change", "feat!: x\n\nBREAKING CHANGE: gone", "feat!: x\n\nBREAKING CHANGE: gone\nRefs: A-1"},
+		{"subject looking like a trailer", "Fixes: stuff", "Fixes: stuff\n\nRefs: A-1"},
+		{"body mixing prose and trailers", "fix: x\n\nNote: this\nand more", "fix: x\n\nNote: this\nand more\n\nRefs: A-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := appendFooter(tt.message, "Refs: A-1"); got != tt.want {
+				t.Errorf("appendFooter() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewTicketInjector(t *testing.T) {
+	tests := []struct {
+		name string
+		opts TicketOptions
+		err  error
+		none bool
+	}{
+		{"disabled", TicketOptions{Pattern: config.DefaultTicketPattern, Placement: config.TicketNone}, nil, true},
+		{"no pattern", TicketOptions{Placement: config.TicketPrefix}, nil, true},
+		{"unknown placement", TicketOptions{Pattern: config.DefaultTicketPattern, Placement: "suffix"}, ErrUnknownTicketPlacement, true},
+		{"invalid pattern", TicketOptions{Pattern: "([A-Z", Placement: config.TicketPrefix}, ErrInvalidTicketPattern, true},
+		{"invalid template", TicketOptions{Pattern: config.DefaultTicketPattern, Placement: config.TicketPrefix, Template: "{{.ID"}, ErrInvalidTicketTemplate, true},
+		{"valid", TicketOptions{Pattern: config.DefaultTicketPattern, Placement: config.TicketFooter}, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ticket, err := newTicketInjector(tt.opts)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("err = %v, want %v", err, tt.err)
+			}
+			if (ticket == nil) != tt.none {
+				t.Errorf("injector = %v, want nil: %v", ticket, tt.none)
+			}
+		})
+	}
+}
+
+func TestTicketTemplateError(t *testing.T) {
+	ticket, err := newTicketInjector(TicketOptions{Pattern: config.DefaultTicketPattern, Placement: config.TicketPrefix, Template: "{{.Missing}}"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ticket.apply("fix: x", "PROJ-1"); !errors.Is(err, ErrInvalidTicketTemplate) {
+		t.Errorf("err = %v, want %v", err, ErrInvalidTicketTemplate)
+	}
+}
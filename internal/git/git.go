@@ -0,0 +1,200 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// run executes git with the given arguments and returns its trimmed output
+func run(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("git %s failed: %s (%w)", strings.Join(args, " "), strings.TrimSpace(string(exitErr.Stderr)), err)
+		}
+		return "", fmt.Errorf("git %s failed: %w", strings.Join(args, " "), err)
+	}
+	return strings.TrimRight(string(output), "\n"), nil
+}
+
+// lines splits command output into non-empty lines
+func lines(output string) []string {
+	var result []string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) != "" {
+			result = append(result, line)
+		}
+	}
+	return result
+}
+
+// TopLevel returns the absolute path of the repository's working tree root
+func TopLevel() (string, error) {
+	return run("rev-parse", "--show-toplevel")
+}
+
+// CurrentBranch returns the name of the checked out branch, or "HEAD" when detached
+func CurrentBranch() (string, error) {
+	// symbolic-ref works even before the first commit, unlike rev-parse --abbrev-ref
+	branch, err := run("symbolic-ref", "--short", "-q", "HEAD")
+	if err != nil || branch == "" {
+		return "HEAD", nil
+	}
+	return branch, nil
+}
+
+// StagedFiles returns the paths of all staged files
+func StagedFiles() ([]string, error) {
+	output, err := run("diff", "--staged", "--name-only")
+	if err != nil {
+		return nil, err
+	}
+	return lines(output), nil
+}
+
+// RecentCommitSubjects returns the subjects of the last n commits, newest first.
+// A repository without commits yields an empty list.
+func RecentCommitSubjects(n int) ([]string, error) {
+	if _, err := run("rev-parse", "--verify", "-q", "HEAD"); err != nil {
+		return nil, nil
+	}
+
+	output, err := run("log", fmt.Sprintf("-n%d", n), "--format=%s")
+	if err != nil {
+		return nil, err
+	}
+	return lines(output), nil
+}
+
+// Change is a staged change to a single file
+type Change struct {
+	// Status is the change letter reported by git: A, M, D, R, C or T
+	Status string
+	// Path is the path of the file, the new one for renames and copies
+	Path string
+	// OldPath is the original path of a renamed or copied file
+	OldPath string
+}
+
+// StagedChanges returns the staged changes with renames detected
+func StagedChanges() ([]Change, error) {
+	output, err := run("diff", "--staged", "--name-status", "-M")
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	for _, line := range lines(output) {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 2 {
+			continue
+		}
+		// Renames and copies carry a similarity score, e.g. R087
+		change := Change{Status: fields[0][:1], Path: fields[len(fields)-1]}
+		if len(fields) == 3 {
+			change.OldPath = fields[1]
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// StagedStat returns the output of git diff --staged --stat, listing at most
+// maxFiles files followed by the summary line
+func StagedStat(maxFiles int) (string, error) {
+	return run("diff", "--staged", "--no-color", "--stat=100", fmt.Sprintf("--stat-count=%d", maxFiles))
+}
+
+// DefaultBranch returns the branch the remote considers its default, falling
+// back to a local main, master or trunk branch, and finally to HEAD
+func DefaultBranch() string {
+	if ref, err := run("symbolic-ref", "-q", "--short", "refs/remotes/origin/HEAD"); err == nil && ref != "" {
+		return ref
+	}
+	for _, branch := range []string{"main", "master", "trunk"} {
+		if _, err := run("rev-parse", "--verify", "-q", "refs/heads/"+branch); err == nil {
+			return branch
+		}
+	}
+	return "HEAD"
+}
+
+// CommitMessages returns the full messages of up to n non-merge commits
+// reachable from ref, newest first, limited to commits touching paths when
+// any are given. Paths are relative to the top of the working tree, as git
+// diff prints them. A repository without commits yields an empty list.
+func CommitMessages(ref string, n int, paths []string) ([]string, error) {
+	if _, err := run("rev-parse", "--verify", "-q", ref); err != nil {
+		return nil, nil
+	}
+
+	// Messages are separated by NUL bytes since they span several lines
+	args := []string{"log", ref, fmt.Sprintf("-n%d", n), "--no-merges", "--format=%B%x00", "--"}
+	for _, path := range paths {
+		// Without the magic, git resolves paths from the current directory
+		args = append(args, ":(top,literal)"+path)
+	}
+	output, err := run(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []string
+	for _, message := range strings.Split(output, "\x00") {
+		if message = strings.TrimSpace(message); message != "" {
+			messages = append(messages, message)
+		}
+	}
+	return messages, nil
+}
+
+// Commit is a commit's abbreviated hash and full message
+type Commit struct {
+	Hash    string
+	Message string
+}
+
+// CommitsInRange returns the non-merge commits of a revision range such as
+// main..HEAD, newest first
+func CommitsInRange(revisions string) ([]Commit, error) {
+	// Fields are separated by a unit separator and commits by NUL bytes
+	output, err := run("log", "--no-merges", "--format=%h%x1f%B%x00", revisions, "--")
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []Commit
+	for _, record := range strings.Split(output, "\x00") {
+		hash, message, ok := strings.Cut(strings.TrimLeft(record, "\n"), "\x1f")
+		if ok {
+			commits = append(commits, Commit{Hash: hash, Message: strings.TrimSpace(message)})
+		}
+	}
+	return commits, nil
+}
+
+// StagedDiff returns the staged diff without colors or external diff tools,
+// with the a/ and b/ path prefixes whatever diff.noprefix and
+// diff.mnemonicPrefix are set to
+func StagedDiff() (string, error) {
+	return run("diff", "--staged", "--no-ext-diff", "--no-color", "--src-prefix=a/", "--dst-prefix=b/")
+}
+
+// Editor returns the editor git runs for commit messages, resolved by git
+// from GIT_EDITOR, core.editor, VISUAL and EDITOR
+func Editor() (string, error) {
+	return run("var", "GIT_EDITOR")
+}
+
+// CommentChar returns the character starting comment lines in commit
+// messages (core.commentChar), "#" unless configured otherwise
+func CommentChar() string {
+	char, err := run("config", "core.commentChar")
+	// "auto" picks a character unused in the message; "#" is what it picks for most
+	if err != nil || char == "" || char == "auto" {
+		return "#"
+	}
+	return char
+}
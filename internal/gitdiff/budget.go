@@ -0,0 +1,179 @@
+package gitdiff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Result is a diff trimmed to fit a token budget
+type Result struct {
+	// Diff is the trimmed diff, followed by a summary of what was left out
+	Diff string
+	// Tokens is the estimated size of Diff
+	Tokens int
+	// OriginalTokens is the estimated size of the untrimmed diff
+	OriginalTokens int
+	// Truncated lists files of which only some hunks were kept
+	Truncated []File
+	// Omitted lists files whose changes were left out entirely
+	Omitted []File
+}
+
+// Trimmed reports whether anything was left out of the diff
+func (r Result) Trimmed() bool {
+	return len(r.Truncated) > 0 || len(r.Omitted) > 0
+}
+
+// omittedNote introduces the list of omitted files appended to a trimmed diff
+const omittedNote = "\n[The following changes were omitted to fit the model's context window]\n"
+
+// Fit trims the files' diff to at most budget tokens. Files are kept in
+// priority order (source, then docs, then generated files, then binaries),
+// smallest first within the same priority so that as many files as possible
+// survive intact. Room left over goes to the leading hunks of files that
+// didn't fit, and files with no room at all are summarized by their line
+// counts so the model still knows they changed.
+func Fit(files []File, budget int) Result {
+	full := make([]string, len(files))
+	var original strings.Builder
+	for i, f := range files {
+		full[i] = f.String()
+		original.WriteString(full[i])
+	}
+
+	result := Result{OriginalTokens: EstimateTokens(original.String())}
+	if result.OriginalTokens <= budget {
+		result.Diff = original.String()
+		result.Tokens = result.OriginalTokens
+		return result
+	}
+
+	order := make([]int, len(files))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		pa, pb := PriorityOf(files[order[a]]), PriorityOf(files[order[b]])
+		if pa != pb {
+			return pa < pb
+		}
+		return len(full[order[a]]) < len(full[order[b]])
+	})
+
+	// Reserve room to summarize every file, refunded as files are kept whole
+	remaining := budget - EstimateTokens(omittedNote)
+	for _, f := range files {
+		remaining -= EstimateTokens(summaryLine(f))
+	}
+
+	// kept holds the number of hunks kept per file, -1 when the file is omitted,
+	// and partial the leading lines kept of the hunk that follows them
+	kept := make([]int, len(files))
+	partial := make([]string, len(files))
+	// Keep whole files first, so one large file can't crowd out several small ones
+	for _, i := range order {
+		f := files[i]
+		kept[i] = -1
+		// Kept files aren't summarized, so their reservation is available to them
+		available := remaining + EstimateTokens(summaryLine(f))
+		if cost := EstimateTokens(full[i]); cost <= available {
+			kept[i] = len(f.Hunks)
+			remaining = available - cost
+		}
+	}
+
+	// Then fill what's left with the leading hunks of the remaining files
+	for _, i := range order {
+		f := files[i]
+		if kept[i] >= 0 || f.Binary {
+			continue
+		}
+		available := remaining + EstimateTokens(summaryLine(f))
+
+		cost := EstimateTokens(f.Header) + EstimateTokens(truncatedLine(f))
+		n := 0
+		for _, hunk := range f.Hunks {
+			if cost+EstimateTokens(hunk) > available {
+				break
+			}
+			cost += EstimateTokens(hunk)
+			n++
+		}
+		if n < len(f.Hunks) {
+			partial[i] = leadingLines(f.Hunks[n], available-cost)
+			cost += EstimateTokens(partial[i])
+		}
+		// A header without any changes says no more than the summary line
+		if n > 0 || partial[i] != "" {
+			kept[i] = n
+			remaining = available - cost
+		}
+	}
+
+	var b strings.Builder
+	for i, f := range files {
+		switch {
+		case kept[i] == len(f.Hunks):
+			b.WriteString(full[i])
+		case kept[i] >= 0:
+			b.WriteString(f.Header)
+			b.WriteString(strings.Join(f.Hunks[:kept[i]], ""))
+			b.WriteString(partial[i])
+			b.WriteString(truncatedLine(f))
+			result.Truncated = append(result.Truncated, f)
+		default:
+			result.Omitted = append(result.Omitted, f)
+		}
+	}
+
+	if len(result.Omitted) > 0 {
+		b.WriteString(omittedNote)
+		for _, f := range result.Omitted {
+			b.WriteString(summaryLine(f))
+		}
+	}
+
+	result.Diff = b.String()
+	// A budget too small for even the summary of omitted files is met by
+	// cutting the summary short
+	if EstimateTokens(result.Diff) > budget {
+		result.Diff = TruncateTokens(result.Diff, max(budget, 0))
+	}
+	result.Tokens = EstimateTokens(result.Diff)
+	return result
+}
+
+// summaryLine describes an omitted file in the trimmed diff
+func summaryLine(f File) string {
+	if f.Binary {
+		return fmt.Sprintf("%s (binary)\n", f.Path)
+	}
+	return fmt.Sprintf("%s (+%d -%d)\n", f.Path, f.Added, f.Deleted)
+}
+
+// truncatedLine marks the end of a truncated file
+func truncatedLine(f File) string {
+	return fmt.Sprintf("[remaining changes to %s omitted]\n", f.Path)
+}
+
+// minPartialLines is the fewest lines of a hunk worth keeping on their own
+const minPartialLines = 4
+
+// leadingLines returns the whole lines at the start of hunk that fit in
+// budget tokens, or an empty string if too few of them fit to be useful
+func leadingLines(hunk string, budget int) string {
+	n, end := 0, 0
+	for end < len(hunk) {
+		next := strings.IndexByte(hunk[end:], '\n')
+		if next < 0 || TokensForBytes(end+next+1) > budget {
+			break
+		}
+		end += next + 1
+		n++
+	}
+	if n < minPartialLines {
+		return ""
+	}
+	return hunk[:end]
+}
@@ -0,0 +1,149 @@
+package gitdiff
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// fileWithHunks returns a file of n hunks, each adding lines lines of about 30 bytes
+func fileWithHunks(path string, n, lines int) File {
+	var diff strings.Builder
+	fmt.Fprintf(&diff, "diff --git a/%s b/%s\n--- a/%s\n+++ b/%s\n", path, path, path, path)
+	for h := 0; h < n; h++ {
+		fmt.Fprintf(&diff, "@@ -%d,0 +%d,%d @@\n", h*100, h*100, lines)
+		for l := 0; l < lines; l++ {
+			fmt.Fprintf(&diff, "+line %03d of hunk %d in the file\n", l, h)
+		}
+	}
+	return Parse(diff.String())[0]
+}
+
+// names returns the paths of the files
+func names(files []File) []string {
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+	return paths
+}
+
+func TestFitUnderBudget(t *testing.T) {
+	files := []File{fileWithHunks("a.go", 1, 5), fileWithHunks("b.go", 2, 5)}
+	result := Fit(files, 100000)
+
+	if result.Trimmed() || result.Tokens != result.OriginalTokens {
+		t.Errorf("result = %+v, want the diff untouched", result)
+	}
+	if result.Diff != files[0].String()+files[1].String() {
+		t.Errorf("diff changed:\n%s", result.Diff)
+	}
+}
+
+func TestFit(t *testing.T) {
+	small := fileWithHunks("small.go", 1, 5)
+	large := fileWithHunks("large.go", 4, 40)
+	lock := fileWithHunks("package-lock.json", 1, 100)
+	readme := fileWithHunks("README.md", 1, 5)
+	image := File{Path: "logo.png", Header: "diff --git a/logo.png b/logo.png\nBinary files differ\n", Binary: true}
+	files := []File{large, lock, small, image, readme}
+
+	result := Fit(files, 1200)
+
+	if result.Tokens > 1200 {
+		t.Errorf("got %d tokens, want at most 1200", result.Tokens)
+	}
+	for _, f := range []File{small, readme, image} {
+		if !strings.Contains(result.Diff, f.String()) {
+			t.Errorf("%s should be kept whole:\n%s", f.Path, result.Diff)
+		}
+	}
+	if got := names(result.Truncated); len(got) != 1 || got[0] != "large.go" {
+		t.Errorf("truncated = %v, want [large.go]", got)
+	}
+	if !strings.Contains(result.Diff, large.Hunks[0]) || strings.Contains(result.Diff, large.Hunks[3]) {
+		t.Error("large.go should keep its leading hunks only")
+	}
+	if !strings.Contains(result.Diff, "[remaining changes to large.go omitted]\n") {
+		t.Error("truncated file isn't marked")
+	}
+	// Source files get the room left over before generated ones
+	if got := names(result.Omitted); len(got) != 1 || got[0] != "package-lock.json" {
+		t.Errorf("omitted = %v, want the lock file", got)
+	}
+	if !strings.Contains(result.Diff, omittedNote+"package-lock.json (+100 -0)\n") {
+		t.Errorf("summary of the lock file missing:\n%s", result.Diff)
+	}
+}
+
+func TestFitPartialHunk(t *testing.T) {
+	f := fileWithHunks("big.go", 1, 200)
+	result := Fit([]File{f}, 500)
+
+	if len(result.Truncated) != 1 {
+		t.Fatalf("truncated = %v, want big.go", names(result.Truncated))
+	}
+	if !strings.Contains(result.Diff, "+line 000 of hunk 0") || strings.Contains(result.Diff, "+line 199 of hunk 0") {
+		t.Errorf("want the leading lines of the hunk:\n%s", result.Diff)
+	}
+}
+
+func TestFitStaysWithinBudget(t *testing.T) {
+	files := []File{
+		fileWithHunks("a.go", 3, 30),
+		fileWithHunks("b/c/d.go", 2, 50),
+		fileWithHunks("yarn.lock", 1, 100),
+		{Path: "font.woff2", Header: "diff --git a/font.woff2 b/font.woff2\nBinary files differ\n", Binary: true},
+	}
+
+	for _, budget := range []int{0, 1, 10, 30, 50, 100, 250, 600, 1500, 3000} {
+		t.Run(fmt.Sprint(budget), func(t *testing.T) {
+			result := Fit(files, budget)
+			if result.Tokens > budget || EstimateTokens(result.Diff) != result.Tokens {
+				t.Errorf("got %d tokens (%d estimated), want at most %d:\n%s",
+					result.Tokens, EstimateTokens(result.Diff), budget, result.Diff)
+			}
+			if !result.Trimmed() {
+				t.Error("result isn't marked as trimmed")
+			}
+		})
+	}
+}
+
+func TestTruncateTokens(t *testing.T) {
+	tests := []struct {
+		s      string
+		tokens int
+		want   string
+	}{
+		{"short", 10, "short"},
+		{"line one\nline two\n", 4, "line one\n"},
+		{"no newlines at all", 2, "no new"},
+		{"abé", 1, "ab"},
+	}
+
+	for _, tt := range tests {
+		if got := TruncateTokens(tt.s, tt.tokens); got != tt.want {
+			t.Errorf("TruncateTokens(%q, %d) = %q, want %q", tt.s, tt.tokens, got, tt.want)
+		}
+	}
+}
+
+func TestContextWindow(t *testing.T) {
+	tests := []struct {
+		model string
+		want  int
+	}{
+		{"gpt-4o-mini", 128000},
+		{"gpt-4", 8192},
+		{"gpt-4-turbo-2024-04-09", 128000},
+		{"claude-3-5-sonnet-latest", 200000},
+		{"llama3.2", DefaultContextWindow},
+	}
+
+	for _, tt := range tests {
+		if got := ContextWindow(tt.model); got != tt.want {
+			t.Errorf("ContextWindow(%q) = %d, want %d", tt.model, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package gitdiff
+
+import "path"
+
+// Grouping selects which files Chunk keeps together
+type Grouping string
+
+const (
+	// ByFile packs files into chunks without regard to where they live
+	ByFile Grouping = "file"
+	// ByDir keeps files of the same directory in the same chunk when they fit
+	ByDir Grouping = "dir"
+)
+
+// Groupings returns the supported groupings
+func Groupings() []Grouping {
+	return []Grouping{ByFile, ByDir}
+}
+
+// Chunk splits files into chunks of at most budget tokens each, keeping the
+// diff order. A group of files larger than the budget is split up, and a
+// single file larger than the budget gets a chunk of its own, to be trimmed
+// with Fit.
+func Chunk(files []File, by Grouping, budget int) [][]File {
+	var chunks [][]File
+	var current []File
+	currentTokens := 0
+
+	flush := func() {
+		if len(current) > 0 {
+			chunks = append(chunks, current)
+			current, currentTokens = nil, 0
+		}
+	}
+	add := func(group []File, tokens int) {
+		if currentTokens+tokens > budget {
+			flush()
+		}
+		current = append(current, group...)
+		currentTokens += tokens
+	}
+
+	for _, group := range groupFiles(files, by) {
+		tokens := 0
+		for _, f := range group {
+			tokens += EstimateTokens(f.String())
+		}
+		if tokens <= budget {
+			add(group, tokens)
+			continue
+		}
+
+		// Too large to keep together, fall back to packing the files one by one
+		for _, f := range group {
+			add([]File{f}, EstimateTokens(f.String()))
+		}
+	}
+	flush()
+
+	return chunks
+}
+
+// groupFiles groups files by the grouping, in order of first appearance
+func groupFiles(files []File, by Grouping) [][]File {
+	if by != ByDir {
+		groups := make([][]File, len(files))
+		for i, f := range files {
+			groups[i] = []File{f}
+		}
+		return groups
+	}
+
+	var groups [][]File
+	index := make(map[string]int)
+	for _, f := range files {
+		dir := path.Dir(f.Path)
+		i, ok := index[dir]
+		if !ok {
+			i = len(groups)
+			index[dir] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], f)
+	}
+	return groups
+}
@@ -0,0 +1,72 @@
+package gitdiff
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// fileOfSize returns a file whose diff is roughly tokens tokens long
+func fileOfSize(path string, tokens int) File {
+	header := fmt.Sprintf("diff --git a/%s b/%s\n", path, path)
+	body := strings.Repeat("x", tokens*bytesPerToken-len(header))
+	return File{Path: path, Header: header + body}
+}
+
+func TestChunk(t *testing.T) {
+	files := []File{
+		fileOfSize("a/one.go", 40),
+		fileOfSize("b/two.go", 40),
+		fileOfSize("a/three.go", 40),
+		fileOfSize("c/huge.go", 500),
+		fileOfSize("b/four.go", 20),
+	}
+
+	tests := []struct {
+		name   string
+		by     Grouping
+		budget int
+		want   [][]string
+	}{
+		{"by file", ByFile, 100, [][]string{
+			{"a/one.go", "b/two.go"},
+			{"a/three.go"},
+			{"c/huge.go"},
+			{"b/four.go"},
+		}},
+		{"by dir", ByDir, 100, [][]string{
+			{"a/one.go", "a/three.go"},
+			{"b/two.go", "b/four.go"},
+			{"c/huge.go"},
+		}},
+		{"directory larger than the budget", ByDir, 60, [][]string{
+			{"a/one.go"},
+			{"a/three.go"},
+			{"b/two.go", "b/four.go"},
+			{"c/huge.go"},
+		}},
+		{"everything fits", ByFile, 1000, [][]string{
+			{"a/one.go", "b/two.go", "a/three.go", "c/huge.go", "b/four.go"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := Chunk(files, tt.by, tt.budget)
+
+			var got [][]string
+			for _, chunk := range chunks {
+				got = append(got, names(chunk))
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("Chunk() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChunkEmpty(t *testing.T) {
+	if chunks := Chunk(nil, ByDir, 100); len(chunks) != 0 {
+		t.Errorf("Chunk(nil) = %v, want no chunks", chunks)
+	}
+}
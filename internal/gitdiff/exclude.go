@@ -0,0 +1,187 @@
+package gitdiff
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFileName is the file at the top of a repository listing extra exclude patterns
+const IgnoreFileName = ".coignore"
+
+// DefaultExcludes are files whose diff rarely helps describe a change: lock
+// files, generated and minified code, and vendored dependencies
+var DefaultExcludes = []string{
+	// Lock files
+	"go.sum", "package-lock.json", "npm-shrinkwrap.json", "yarn.lock", "pnpm-lock.yaml",
+	"bun.lockb", "Cargo.lock", "poetry.lock", "Pipfile.lock", "uv.lock", "Gemfile.lock",
+	"composer.lock", "mix.lock", "flake.lock", "*.lock",
+	// Generated and minified code
+	"*.min.js", "*.min.css", "*.map", "*.pb.go", "*_generated.go", "*.gen.go", "*_gen.go",
+	"*.snap", "__generated__/",
+	// Vendored dependencies and build output
+	"vendor/", "node_modules/", "third_party/", "dist/",
+}
+
+// defaultMatcher matches DefaultExcludes, which are known to be valid
+var defaultMatcher = mustMatcher(DefaultExcludes)
+
+// Matcher matches file paths against gitignore style patterns:
+//   - a pattern without a slash matches the file or directory name at any depth
+//   - a pattern containing a slash is relative to the repository root
+//   - a trailing slash only matches directories
+//   - "*" and "?" don't cross directories, "**" does
+//   - a leading "!" re-includes paths excluded by an earlier pattern
+type Matcher struct {
+	rules []matchRule
+}
+
+type matchRule struct {
+	re     *regexp.Regexp
+	negate bool
+}
+
+// NewMatcher compiles the patterns. Empty patterns and comments starting with # are skipped.
+func NewMatcher(patterns []string) (*Matcher, error) {
+	m := &Matcher{}
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+
+		negate := strings.HasPrefix(pattern, "!")
+		re, err := regexp.Compile(globRegexp(strings.TrimPrefix(pattern, "!")))
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude pattern %q: %w", pattern, err)
+		}
+		m.rules = append(m.rules, matchRule{re: re, negate: negate})
+	}
+	return m, nil
+}
+
+func mustMatcher(patterns []string) *Matcher {
+	m, err := NewMatcher(patterns)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// Match reports whether the path is excluded; the last matching pattern wins.
+// A nil Matcher excludes nothing.
+func (m *Matcher) Match(p string) bool {
+	if m == nil {
+		return false
+	}
+	excluded := false
+	for _, rule := range m.rules {
+		if rule.re.MatchString(p) {
+			excluded = !rule.negate
+		}
+	}
+	return excluded
+}
+
+// globRegexp translates a gitignore style pattern to an anchored regular expression
+func globRegexp(pattern string) string {
+	var b strings.Builder
+	b.WriteString("^")
+
+	dirOnly := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+	if strings.HasPrefix(pattern, "/") || strings.Contains(pattern, "/") {
+		pattern = strings.TrimPrefix(pattern, "/")
+	} else {
+		b.WriteString("(.*/)?")
+	}
+
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	if dirOnly {
+		b.WriteString("/.*$")
+	} else {
+		// Like git, a pattern naming a directory also excludes everything in it
+		b.WriteString("(/.*)?$")
+	}
+	return b.String()
+}
+
+// ReadIgnoreFile reads the patterns of an ignore file, one per line. A missing file has no patterns.
+func ReadIgnoreFile(name string) ([]string, error) {
+	f, err := os.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		patterns = append(patterns, scanner.Text())
+	}
+	return patterns, scanner.Err()
+}
+
+// Exclude splits files into those to send to the model and those excluded by
+// the matcher. Binary files are excluded too when excludeBinary is set, their
+// diff being nothing but a marker.
+func Exclude(files []File, m *Matcher, excludeBinary bool) (kept, excluded []File) {
+	for _, f := range files {
+		if m.Match(f.Path) || (excludeBinary && f.Binary) {
+			excluded = append(excluded, f)
+		} else {
+			kept = append(kept, f)
+		}
+	}
+	return kept, excluded
+}
+
+// ExcludedNote lists excluded files with their stats, to be appended to the
+// diff so the model can still mention them
+func ExcludedNote(files []File) string {
+	if len(files) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("\n[The following files changed but their diff was excluded]\n")
+	for _, f := range files {
+		b.WriteString(summaryLine(f))
+	}
+	return b.String()
+}
@@ -0,0 +1,167 @@
+package gitdiff
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestMatcher(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		path     string
+		want     bool
+	}{
+		// A pattern without a slash matches the name at any depth
+		{[]string{"*.lock"}, "Cargo.lock", true},
+		{[]string{"*.lock"}, "sub/dir/poetry.lock", true},
+		{[]string{"*.lock"}, "lock.go", false},
+		{[]string{"go.sum"}, "tools/go.sum", true},
+		// "*" and "?" stay within a directory
+		{[]string{"docs/*.md"}, "docs/guide.md", true},
+		{[]string{"docs/*.md"}, "docs/api/ref.md", false},
+		{[]string{"file?.txt"}, "file1.txt", true},
+		{[]string{"file?.txt"}, "file10.txt", false},
+		// A pattern with a slash is relative to the root
+		{[]string{"build/out"}, "build/out/app", true},
+		{[]string{"build/out"}, "src/build/out", false},
+		{[]string{"/Makefile"}, "Makefile", true},
+		{[]string{"/Makefile"}, "sub/Makefile", false},
+		// "**" crosses directories
+		{[]string{"**/testdata/**"}, "a/b/testdata/x/y.json", true},
+		{[]string{"api/**/*.pb.go"}, "api/v1/user.pb.go", true},
+		{[]string{"api/**/*.pb.go"}, "api/user.pb.go", true},
+		// A trailing slash only matches directories
+		{[]string{"vendor/"}, "vendor/github.com/x/y.go", true},
+		{[]string{"vendor/"}, "pkg/vendor/z.go", true},
+		{[]string{"vendor/"}, "vendor", false},
+		// Character classes
+		{[]string{"*.[ch]"}, "main.c", true},
+		{[]string{"*.[!ch]"}, "main.c", false},
+		{[]string{"*.[!ch]"}, "main.o", true},
+		{[]string{"a[b"}, "a[b", true},
+		// Negation, where the last matching pattern wins
+		{[]string{"*.json", "!package.json"}, "package.json", false},
+		{[]string{"*.json", "!package.json"}, "tsconfig.json", true},
+		{[]string{"!keep.go", "*.go"}, "keep.go", true},
+		// Comments and blank lines
+		{[]string{"# *.go", "", "  "}, "main.go", false},
+		// Regexp metacharacters are literal
+		{[]string{"a+b.txt"}, "aab.txt", false},
+		{[]string{"a+b.txt"}, "a+b.txt", true},
+	}
+
+	for _, tt := range tests {
+		m, err := NewMatcher(tt.patterns)
+		if err != nil {
+			t.Fatalf("NewMatcher(%q): %v", tt.patterns, err)
+		}
+		if got := m.Match(tt.path); got != tt.want {
+			t.Errorf("%q matching %q = %v, want %v (regexp %s)", tt.patterns, tt.path, got, tt.want, globRegexp(tt.patterns[len(tt.patterns)-1]))
+		}
+	}
+}
+
+func TestGlobRegexp(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{"*.lock", `^(.*/)?[^/]*\.lock(/.*)?$`},
+		{"/build", `^build(/.*)?$`},
+		{"dist/", `^(.*/)?dist/.*$`},
+		{"a/**/b", `^a/(.*/)?b(/.*)?$`},
+		{"x**", `^(.*/)?x.*(/.*)?$`},
+		{"[!a-c]?", `^(.*/)?[^a-c][^/](/.*)?$`},
+	}
+
+	for _, tt := range tests {
+		if got := globRegexp(tt.pattern); got != tt.want {
+			t.Errorf("globRegexp(%q) = %s, want %s", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestMatcherNil(t *testing.T) {
+	var m *Matcher
+	if m.Match("anything") {
+		t.Error("a nil matcher must exclude nothing")
+	}
+}
+
+func TestDefaultExcludes(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"go.sum", true},
+		{"web/package-lock.json", true},
+		{"static/app.min.js", true},
+		{"api/user.pb.go", true},
+		{"node_modules/react/index.js", true},
+		{"src/__generated__/types.ts", true},
+		{"go.mod", false},
+		{"cmd/root.go", false},
+		{"src/distance.go", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsGenerated(tt.path); got != tt.want {
+			t.Errorf("IsGenerated(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestExclude(t *testing.T) {
+	files := []File{{Path: "main.go"}, {Path: "go.sum"}, {Path: "logo.png", Binary: true}}
+	m := mustMatcher([]string{"go.sum"})
+
+	for _, tt := range []struct {
+		excludeBinary bool
+		kept          []string
+		excluded      []string
+	}{
+		{false, []string{"main.go", "logo.png"}, []string{"go.sum"}},
+		{true, []string{"main.go"}, []string{"go.sum", "logo.png"}},
+	} {
+		kept, excluded := Exclude(files, m, tt.excludeBinary)
+		if !slices.Equal(names(kept), tt.kept) || !slices.Equal(names(excluded), tt.excluded) {
+			t.Errorf("Exclude(binary: %v) = %v, %v, want %v, %v",
+				tt.excludeBinary, names(kept), names(excluded), tt.kept, tt.excluded)
+		}
+	}
+}
+
+func TestExcludedNote(t *testing.T) {
+	if note := ExcludedNote(nil); note != "" {
+		t.Errorf("ExcludedNote(nil) = %q, want empty", note)
+	}
+
+	note := ExcludedNote([]File{{Path: "go.sum", Added: 3, Deleted: 1}, {Path: "logo.png", Binary: true}})
+	want := "\n[The following files changed but their diff was excluded]\ngo.sum (+3 -1)\nlogo.png (binary)\n"
+	if note != want {
+		t.Errorf("ExcludedNote() = %q, want %q", note, want)
+	}
+}
+
+func TestReadIgnoreFile(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, IgnoreFileName)
+
+	patterns, err := ReadIgnoreFile(name)
+	if err != nil || patterns != nil {
+		t.Errorf("missing file = %q, %v, want no patterns", patterns, err)
+	}
+
+	if err := os.WriteFile(name, []byte("# fixtures\ntestdata/\n!testdata/keep.txt\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	patterns, err = ReadIgnoreFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"# fixtures", "testdata/", "!testdata/keep.txt"}; !slices.Equal(patterns, want) {
+		t.Errorf("patterns = %q, want %q", patterns, want)
+	}
+}
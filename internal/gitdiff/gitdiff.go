@@ -0,0 +1,125 @@
+package gitdiff
+
+import (
+	"path"
+	"strings"
+)
+
+// File is the part of a unified git diff describing a single file
+type File struct {
+	// Path is the path of the file after the change (before it, for deletions)
+	Path string
+	// Header holds the "diff --git", index, mode and ---/+++ lines
+	Header string
+	// Hunks holds each "@@" hunk including its header line
+	Hunks []string
+	// Binary is set for binary files, which have no textual hunks
+	Binary bool
+	// Added and Deleted count the changed lines
+	Added, Deleted int
+}
+
+// String returns the file's diff as git printed it
+func (f File) String() string {
+	return f.Header + strings.Join(f.Hunks, "")
+}
+
+// Parse splits the output of git diff into files. Text before the first
+// "diff --git" line, if any, is ignored.
+func Parse(diff string) []File {
+	var files []File
+	var current *File
+	var hunk strings.Builder
+
+	flushHunk := func() {
+		if current != nil && hunk.Len() > 0 {
+			current.Hunks = append(current.Hunks, hunk.String())
+			hunk.Reset()
+		}
+	}
+
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		if line == "" {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flushHunk()
+			files = append(files, File{Path: pathFromDiffLine(line), Header: line})
+			current = &files[len(files)-1]
+
+		case current == nil:
+			continue
+
+		case strings.HasPrefix(line, "@@"):
+			flushHunk()
+			hunk.WriteString(line)
+
+		case hunk.Len() > 0:
+			hunk.WriteString(line)
+			if strings.HasPrefix(line, "+") {
+				current.Added++
+			} else if strings.HasPrefix(line, "-") {
+				current.Deleted++
+			}
+
+		default:
+			// Still in the file header
+			current.Header += line
+			if strings.HasPrefix(line, "Binary files ") || strings.HasPrefix(line, "GIT binary patch") {
+				current.Binary = true
+			}
+			// The +++ line holds the unambiguous new path (/dev/null for deletions)
+			if p, ok := strings.CutPrefix(strings.TrimRight(line, "\n"), "+++ b/"); ok {
+				current.Path = p
+			}
+		}
+	}
+	flushHunk()
+
+	return files
+}
+
+// pathFromDiffLine extracts the new path from a "diff --git a/x b/x" line
+func pathFromDiffLine(line string) string {
+	line = strings.TrimRight(line, "\n")
+	if i := strings.LastIndex(line, " b/"); i >= 0 {
+		return line[i+len(" b/"):]
+	}
+	return strings.TrimPrefix(line, "diff --git ")
+}
+
+// Priority ranks files by how useful their diff is for describing a change;
+// lower values are kept first when the diff must be trimmed
+type Priority int
+
+const (
+	PrioritySource Priority = iota
+	PriorityDocs
+	PriorityGenerated
+	PriorityBinary
+)
+
+// docExts are extensions of documentation files
+var docExts = map[string]bool{".md": true, ".markdown": true, ".rst": true, ".txt": true, ".adoc": true}
+
+// IsGenerated reports whether the path matches DefaultExcludes, i.e. looks
+// like a generated, vendored, minified or lock file
+func IsGenerated(p string) bool {
+	return defaultMatcher.Match(p)
+}
+
+// PriorityOf ranks the file for trimming
+func PriorityOf(f File) Priority {
+	switch {
+	case f.Binary:
+		return PriorityBinary
+	case IsGenerated(f.Path):
+		return PriorityGenerated
+	case docExts[strings.ToLower(path.Ext(f.Path))]:
+		return PriorityDocs
+	default:
+		return PrioritySource
+	}
+}
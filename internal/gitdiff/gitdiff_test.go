@@ -0,0 +1,101 @@
+package gitdiff
+
+import (
+	"strings"
+	"testing"
+)
+
+const sampleDiff = `diff --git a/main.go b/main.go
+index 83db48f..bf269f4 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,4 @@
+ package main
++
++import "fmt"
+-// old comment
+@@ -10,2 +11,2 @@ func main() {
+-	println("hi")
++	fmt.Println("hi")
+diff --git a/old name.txt b/new name.txt
+similarity index 100%
+rename from old name.txt
+rename to new name.txt
+diff --git a/gone.md b/gone.md
+deleted file mode 100644
+index e69de29..0000000
+--- a/gone.md
++++ /dev/null
+@@ -1 +0,0 @@
+-bye
+diff --git a/logo.png b/logo.png
+new file mode 100644
+index 0000000..d1b2c3e
+Binary files /dev/null and b/logo.png differ
+`
+
+func TestParse(t *testing.T) {
+	files := Parse("warning: ignored preamble\n" + sampleDiff)
+
+	want := []struct {
+		path           string
+		hunks          int
+		added, deleted int
+		binary         bool
+	}{
+		{"main.go", 2, 3, 2, false},
+		{"new name.txt", 0, 0, 0, false},
+		{"gone.md", 1, 0, 1, false},
+		{"logo.png", 0, 0, 0, true},
+	}
+	if len(files) != len(want) {
+		t.Fatalf("got %d files, want %d", len(files), len(want))
+	}
+	for i, w := range want {
+		f := files[i]
+		if f.Path != w.path || len(f.Hunks) != w.hunks || f.Added != w.added || f.Deleted != w.deleted || f.Binary != w.binary {
+			t.Errorf("file %d = {%q hunks:%d +%d -%d binary:%v}, want %+v",
+				i, f.Path, len(f.Hunks), f.Added, f.Deleted, f.Binary, w)
+		}
+	}
+
+	if !strings.HasPrefix(files[0].Hunks[1], "@@ -10,2 +11,2 @@") {
+		t.Errorf("second hunk = %q", files[0].Hunks[1])
+	}
+
+	var joined strings.Builder
+	for _, f := range files {
+		joined.WriteString(f.String())
+	}
+	if joined.String() != sampleDiff {
+		t.Errorf("files don't reproduce the diff:\n%s", joined.String())
+	}
+}
+
+func TestParseEmpty(t *testing.T) {
+	for _, diff := range []string{"", "\n", "no diff here\n"} {
+		if files := Parse(diff); len(files) != 0 {
+			t.Errorf("Parse(%q) = %v, want no files", diff, files)
+		}
+	}
+}
+
+func TestPriorityOf(t *testing.T) {
+	tests := []struct {
+		file File
+		want Priority
+	}{
+		{File{Path: "cmd/root.go"}, PrioritySource},
+		{File{Path: "README.md"}, PriorityDocs},
+		{File{Path: "docs/Guide.RST"}, PriorityDocs},
+		{File{Path: "package-lock.json"}, PriorityGenerated},
+		{File{Path: "vendor/github.com/x/y.go"}, PriorityGenerated},
+		{File{Path: "logo.png", Binary: true}, PriorityBinary},
+	}
+
+	for _, tt := range tests {
+		if got := PriorityOf(tt.file); got != tt.want {
+			t.Errorf("PriorityOf(%q) = %d, want %d", tt.file.Path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package gitdiff
+
+import "strings"
+
+// DefaultContextWindow is assumed for models missing from contextWindows,
+// including local models whose window depends on how the server runs them
+const DefaultContextWindow = 8192
+
+// contextWindows maps model name prefixes to their context window in tokens.
+// The longest matching prefix wins.
+var contextWindows = map[string]int{
+	"gpt-4o":        128000,
+	"gpt-4.1":       1000000,
+	"gpt-4.5":       128000,
+	"gpt-4-turbo":   128000,
+	"gpt-4":         8192,
+	"gpt-3.5-turbo": 16385,
+	"gpt-5":         400000,
+	"o1":            200000,
+	"o3":            200000,
+	"o4":            200000,
+	"claude":        200000,
+}
+
+// ContextWindow returns the context window of the model in tokens
+func ContextWindow(model string) int {
+	best, window := "", DefaultContextWindow
+	for prefix, size := range contextWindows {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+			best, window = prefix, size
+		}
+	}
+	return window
+}
+
+// bytesPerToken is a conservative average for source code and diffs with the
+// common BPE tokenizers, which usually land between three and four
+const bytesPerToken = 3
+
+// EstimateTokens approximates the number of tokens in s. It errs on the high
+// side, which is accurate enough to budget a prompt without shipping a tokenizer.
+func EstimateTokens(s string) int {
+	return TokensForBytes(len(s))
+}
+
+// TokensForBytes approximates the number of tokens in n bytes of text
+func TokensForBytes(n int) int {
+	return (n + bytesPerToken - 1) / bytesPerToken
+}
+
+// TruncateTokens cuts s at a line boundary so that it fits in tokens
+func TruncateTokens(s string, tokens int) string {
+	limit := tokens * bytesPerToken
+	if len(s) <= limit {
+		return s
+	}
+	if i := strings.LastIndexByte(s[:limit], '\n'); i >= 0 {
+		return s[:i+1]
+	}
+	return strings.ToValidUTF8(s[:limit], "")
+}
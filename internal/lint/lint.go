@@ -0,0 +1,255 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Names of the lint rules, following commitlint's where there is an equivalent
+const (
+	RuleMessageEmpty      = "message-empty"
+	RuleHeaderFormat      = "header-format"
+	RuleHeaderMaxLength   = "header-max-length"
+	RuleTypeEnum          = "type-enum"
+	RuleScopeEnum         = "scope-enum"
+	RuleScopeEmpty        = "scope-empty"
+	RuleSubjectEmpty      = "subject-empty"
+	RuleSubjectMood       = "subject-mood"
+	RuleSubjectFullStop   = "subject-full-stop"
+	RuleBodyLeadingBlank  = "body-leading-blank"
+	RuleBodyMaxLineLength = "body-max-line-length"
+	RuleFooterRequired    = "footer-required"
+)
+
+// Rules returns the names of all rules
+func Rules() []string {
+	return []string{
+		RuleMessageEmpty, RuleHeaderFormat, RuleHeaderMaxLength, RuleTypeEnum, RuleScopeEnum,
+		RuleScopeEmpty, RuleSubjectEmpty, RuleSubjectMood, RuleSubjectFullStop,
+		RuleBodyLeadingBlank, RuleBodyMaxLineLength, RuleFooterRequired,
+	}
+}
+
+// Severity tells whether a violation should stop a commit
+type Severity int
+
+const (
+	// Warning flags a likely problem, such as a heuristic match
+	Warning Severity = iota
+	// Error flags a broken convention
+	Error
+)
+
+func (s Severity) String() string {
+	if s == Error {
+		return "error"
+	}
+	return "warning"
+}
+
+// Violation is a rule broken by a commit message
+type Violation struct {
+	// Rule is the name of the broken rule
+	Rule string
+	// Severity tells whether the violation is an error or a warning
+	Severity Severity
+	// Line is the 1-based line of the message the violation is on
+	Line int
+	// Message describes the problem
+	Message string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%d: %s (%s)", v.Line, v.Message, v.Rule)
+}
+
+// DefaultTypes are the Conventional Commits types accepted by default
+var DefaultTypes = []string{"feat", "fix", "docs", "style", "refactor", "perf", "test", "build", "ci", "chore", "revert"}
+
+// Config selects and tunes the rules
+type Config struct {
+	// Conventional requires a "type(scope): subject" header
+	Conventional bool
+	// Types lists the allowed types of conventional headers (empty allows any)
+	Types []string
+	// Scopes lists the allowed scopes of conventional headers (empty allows any)
+	Scopes []string
+	// RequireScope requires conventional headers to have a scope
+	RequireScope bool
+	// SubjectMaxLength limits the length of the first line (0 disables it)
+	SubjectMaxLength int
+	// BodyMaxLineLength limits the length of body lines (0 disables it)
+	BodyMaxLineLength int
+	// Imperative warns about subjects that don't start with an imperative verb
+	Imperative bool
+	// RequiredFooters lists trailer tokens every message must have, e.g. "Signed-off-by"
+	RequiredFooters []string
+	// Disabled lists rules that are never reported
+	Disabled []string
+}
+
+// DefaultConfig returns the rules applied when nothing is configured
+func DefaultConfig() Config {
+	return Config{
+		Conventional:      true,
+		Types:             DefaultTypes,
+		SubjectMaxLength:  72,
+		BodyMaxLineLength: 72,
+		Imperative:        true,
+	}
+}
+
+// HasErrors reports whether any of the violations is an error
+func HasErrors(violations []Violation) bool {
+	for _, v := range violations {
+		if v.Severity == Error {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	// conventionalHeader splits a header into type, scope, breaking marker and subject
+	conventionalHeader = regexp.MustCompile(`^(\w+)(?:\(([^)]*)\))?(!?): ?(.*)$`)
+
+	// trailer matches git trailers and Conventional Commits footers
+	trailer = regexp.MustCompile(`^([\w-]+|BREAKING CHANGE)(: | #)`)
+
+	// ignoredHeader matches messages generated by git itself, which follow
+	// git's conventions rather than the repository's (like commitlint's default ignores)
+	ignoredHeader = regexp.MustCompile(`^(fixup! |squash! |amend! |Merge |Revert ")`)
+
+	// leadingSymbols skips emoji and shortcodes in front of the subject's first word
+	leadingSymbols = regexp.MustCompile(`^(?::\w+:|[^\p{L}])*`)
+)
+
+// Lint checks the message against the configured rules. Lines starting with
+// "#" are expected to have been stripped already. Fixups, merges and reverts
+// created by git are not checked.
+func Lint(message string, cfg Config) []Violation {
+	var violations []Violation
+	report := func(rule string, severity Severity, line int, format string, args ...any) {
+		if !slices.Contains(cfg.Disabled, rule) {
+			violations = append(violations, Violation{Rule: rule, Severity: severity, Line: line, Message: fmt.Sprintf(format, args...)})
+		}
+	}
+
+	message = strings.TrimRight(message, "\n")
+	if strings.TrimSpace(message) == "" {
+		report(RuleMessageEmpty, Error, 1, "message is empty")
+		return violations
+	}
+
+	lines := strings.Split(message, "\n")
+	header := lines[0]
+	if ignoredHeader.MatchString(header) {
+		return nil
+	}
+
+	if n := utf8.RuneCountInString(header); cfg.SubjectMaxLength > 0 && n > cfg.SubjectMaxLength {
+		report(RuleHeaderMaxLength, Error, 1, "subject line is %d characters long, the limit is %d", n, cfg.SubjectMaxLength)
+	}
+
+	subject := header
+	if cfg.Conventional {
+		subject = lintConventionalHeader(header, cfg, report)
+	}
+
+	if strings.TrimSpace(subject) == "" {
+		report(RuleSubjectEmpty, Error, 1, "subject is empty")
+	} else {
+		if strings.HasSuffix(subject, ".") {
+			report(RuleSubjectFullStop, Warning, 1, "subject ends with a full stop")
+		}
+		if cfg.Imperative {
+			if word, suggestion, ok := notImperative(subject); ok {
+				report(RuleSubjectMood, Warning, 1, "subject should use the imperative mood, %q rather than %q", suggestion, word)
+			}
+		}
+	}
+
+	if len(lines) > 1 && strings.TrimSpace(lines[1]) != "" {
+		report(RuleBodyLeadingBlank, Error, 2, "the subject line must be followed by a blank line")
+	}
+
+	if cfg.BodyMaxLineLength > 0 {
+		for i, line := range lines[1:] {
+			// Long URLs and other unbreakable words can't be wrapped
+			if n := utf8.RuneCountInString(line); n > cfg.BodyMaxLineLength && strings.Contains(strings.TrimSpace(line), " ") {
+				report(RuleBodyMaxLineLength, Error, i+2, "line is %d characters long, the limit is %d", n, cfg.BodyMaxLineLength)
+			}
+		}
+	}
+
+	if len(cfg.RequiredFooters) > 0 {
+		footers := trailers(lines[1:])
+		for _, required := range cfg.RequiredFooters {
+			if !slices.ContainsFunc(footers, func(token string) bool { return strings.EqualFold(token, required) }) {
+				report(RuleFooterRequired, Error, len(lines), "the %q footer is required", required)
+			}
+		}
+	}
+
+	return violations
+}
+
+// lintConventionalHeader checks the type and scope of the header and returns its subject
+func lintConventionalHeader(header string, cfg Config, report func(string, Severity, int, string, ...any)) string {
+	m := conventionalHeader.FindStringSubmatch(header)
+	if m == nil || !strings.Contains(header, ": ") {
+		report(RuleHeaderFormat, Error, 1, "subject line must look like \"type(scope): subject\"")
+		return header
+	}
+	kind, scope, subject := m[1], m[2], m[4]
+
+	if len(cfg.Types) > 0 && !slices.Contains(cfg.Types, kind) {
+		report(RuleTypeEnum, Error, 1, "type %q is not one of %s", kind, strings.Join(cfg.Types, ", "))
+	}
+
+	if scope == "" {
+		if cfg.RequireScope {
+			report(RuleScopeEmpty, Error, 1, "a scope is required")
+		}
+	} else if len(cfg.Scopes) > 0 {
+		// Several scopes may be given, separated by commas
+		for _, s := range strings.Split(scope, ",") {
+			if s = strings.TrimSpace(s); !slices.Contains(cfg.Scopes, s) {
+				report(RuleScopeEnum, Error, 1, "scope %q is not one of %s", s, strings.Join(cfg.Scopes, ", "))
+			}
+		}
+	}
+
+	return subject
+}
+
+// trailers returns the tokens of the trailers in the last paragraph of the lines
+func trailers(lines []string) []string {
+	start := 0
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			start = i + 1
+		}
+	}
+
+	var tokens []string
+	for _, line := range lines[start:] {
+		if m := trailer.FindStringSubmatch(line); m != nil {
+			tokens = append(tokens, m[1])
+		}
+	}
+	return tokens
+}
+
+// notImperative reports the first word of the subject when it is a common
+// verb in the past tense, the third person or the -ing form, along with its
+// imperative form
+func notImperative(subject string) (word, suggestion string, ok bool) {
+	subject = leadingSymbols.ReplaceAllString(subject, "")
+	word, _, _ = strings.Cut(subject, " ")
+	suggestion, ok = verbForms[strings.ToLower(word)]
+	return word, suggestion, ok
+}
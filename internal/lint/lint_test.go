@@ -0,0 +1,182 @@
+package lint
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// rules returns the rules of the violations in order
+func rules(violations []Violation) []string {
+	var names []string
+	for _, v := range violations {
+		names = append(names, v.Rule)
+	}
+	return names
+}
+
+func TestLint(t *testing.T) {
+	defaults := DefaultConfig()
+	plain := Config{SubjectMaxLength: 50, BodyMaxLineLength: 72, Imperative: true}
+
+	tests := []struct {
+		name    string
+		message string
+		cfg     Config
+		want    []string
+	}{
+		{"valid", "feat(api): add pagination\n\nPages are 50 items long.\n", defaults, nil},
+		{"empty", "\n\n", defaults, []string{RuleMessageEmpty}},
+		{"not conventional", "Add pagination", defaults, []string{RuleHeaderFormat}},
+		{"missing space", "feat:add pagination", defaults, []string{RuleHeaderFormat}},
+		{"unknown type", "feature: add pagination", defaults, []string{RuleTypeEnum}},
+		{"any type", "feature: add pagination", Config{Conventional: true}, nil},
+		{"empty subject", "feat: ", defaults, []string{RuleSubjectEmpty}},
+		{"full stop", "fix: handle nil maps.", defaults, []string{RuleSubjectFullStop}},
+		{"past tense", "fix: fixed nil maps", defaults, []string{RuleSubjectMood}},
+		{"third person", "Adds pagination", plain, []string{RuleSubjectMood}},
+		{"gerund after emoji", ":sparkles: adding pagination", plain, []string{RuleSubjectMood}},
+		{"mood disabled", "Adds pagination", Config{}, nil},
+		{"breaking change", "feat(api)!: drop v1", defaults, nil},
+		{"subject too long", "feat: " + strings.Repeat("a", 70), defaults, []string{RuleHeaderMaxLength}},
+		{"length counts runes", "fix: " + strings.Repeat("é", 45), plain, nil},
+		{"no blank line", "fix: x\nbody", defaults, []string{RuleBodyLeadingBlank}},
+		{"long body line", "fix: x\n\n" + strings.Repeat("word ", 20), defaults, []string{RuleBodyMaxLineLength}},
+		{"long url", "fix: x\n\nhttps://example.com/" + strings.Repeat("a", 100), defaults, nil},
+		{"fixup", "fixup! Add pagination", defaults, nil},
+		{"merge", "Merge branch 'main' into feature", defaults, nil},
+		{"revert", "Revert \"feat: add pagination\"", defaults, nil},
+		{"disabled rule", "fix: handled it.", Config{Conventional: true, Imperative: true, Disabled: []string{RuleSubjectFullStop}}, []string{RuleSubjectMood}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rules(Lint(tt.message, tt.cfg)); !slices.Equal(got, tt.want) {
+				t.Errorf("Lint(%q) = %v, want %v", tt.message, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintScopes(t *testing.T) {
+	cfg := Config{Conventional: true, Scopes: []string{"api", "ui"}, RequireScope: true}
+
+	tests := []struct {
+		header string
+		want   []string
+	}{
+		{"feat(api): add pagination", nil},
+		{"feat(api, ui): add pagination", nil},
+		{"feat: add pagination", []string{RuleScopeEmpty}},
+		{"feat(db): add index", []string{RuleScopeEnum}},
+		{"feat(api,db,cli): add index", []string{RuleScopeEnum, RuleScopeEnum}},
+	}
+
+	for _, tt := range tests {
+		if got := rules(Lint(tt.header, cfg)); !slices.Equal(got, tt.want) {
+			t.Errorf("Lint(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestLintRequiredFooters(t *testing.T) {
+	cfg := Config{RequiredFooters: []string{"Signed-off-by", "Refs"}}
+
+	tests := []struct {
+		message string
+		want    []string
+	}{
+		{"Add x\n\nBody.\n\nsigned-off-by: A <a@b.c>\nRefs: PROJ-1", nil},
+		{"Add x\n\nRefs #12\nSigned-off-by: A <a@b.c>", nil},
+		{"Add x\n\nSigned-off-by: A <a@b.c>", []string{RuleFooterRequired}},
+		// Trailers only count in the last paragraph
+		{"Add x\n\nSigned-off-by: A <a@b.c>\nRefs: PROJ-1\n\nMore text.", []string{RuleFooterRequired, RuleFooterRequired}},
+	}
+
+	for _, tt := range tests {
+		if got := rules(Lint(tt.message, cfg)); !slices.Equal(got, tt.want) {
+			t.Errorf("Lint(%q) = %v, want %v", tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestLintLinesAndSeverity(t *testing.T) {
+	message := "fix: fixed it.\nbody right away\n" + strings.Repeat("long ", 20)
+	got := Lint(message, DefaultConfig())
+
+	want := []Violation{
+		{Rule: RuleSubjectFullStop, Severity: Warning, Line: 1},
+		{Rule: RuleSubjectMood, Severity: Warning, Line: 1},
+		{Rule: RuleBodyLeadingBlank, Severity: Error, Line: 2},
+		{Rule: RuleBodyMaxLineLength, Severity: Error, Line: 3},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Lint() = %v, want %d violations", got, len(want))
+	}
+	for i, v := range got {
+		if v.Rule != want[i].Rule || v.Severity != want[i].Severity || v.Line != want[i].Line {
+			t.Errorf("violation %d = %s %s line %d, want %s %s line %d",
+				i, v.Rule, v.Severity, v.Line, want[i].Rule, want[i].Severity, want[i].Line)
+		}
+	}
+	if !HasErrors(got) || HasErrors(got[:2]) {
+		t.Error("HasErrors should only report errors, not warnings")
+	}
+}
+
+func TestInflect(t *testing.T) {
+	tests := []struct {
+		verb string
+		want []string
+	}{
+		{"add", []string{"adds", "added", "adding"}},
+		{"remove", []string{"removes", "removed", "removing"}},
+		{"apply", []string{"applies", "applied", "applying"}},
+		{"fix", []string{"fixes", "fixed", "fixing"}},
+		{"refresh", []string{"refreshes", "refreshed", "refreshing"}},
+		{"match", []string{"matches", "matched", "matching"}},
+		{"bypass", []string{"bypasses", "bypassed", "bypassing"}},
+		{"stop", []string{"stops", "stopped", "stopping"}},
+		{"destroy", []string{"destroys", "destroyed", "destroying"}},
+	}
+
+	for _, tt := range tests {
+		if got := inflect(tt.verb); !slices.Equal(got, tt.want) {
+			t.Errorf("inflect(%q) = %v, want %v", tt.verb, got, tt.want)
+		}
+	}
+}
+
+func TestNotImperative(t *testing.T) {
+	tests := []struct {
+		subject    string
+		word       string
+		suggestion string
+	}{
+		{"Added tests", "Added", "add"},
+		{"updates deps", "updates", "update"},
+		{"wrote docs", "wrote", "write"},
+		{"🐛 Fixing crash", "Fixing", "fix"},
+		{"Add tests", "", ""},
+		{"Addition of tests", "", ""},
+		{"README tweaks", "", ""},
+	}
+
+	for _, tt := range tests {
+		word, suggestion, ok := notImperative(tt.subject)
+		if !ok {
+			word, suggestion = "", ""
+		}
+		if word != tt.word || suggestion != tt.suggestion {
+			t.Errorf("notImperative(%q) = %q, %q, want %q, %q", tt.subject, word, suggestion, tt.word, tt.suggestion)
+		}
+	}
+}
+
+func TestViolationString(t *testing.T) {
+	v := Violation{Rule: RuleSubjectEmpty, Severity: Error, Line: 1, Message: "subject is empty"}
+	if got, want := v.String(), fmt.Sprintf("1: subject is empty (%s)", RuleSubjectEmpty); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,70 @@
+package lint
+
+import "strings"
+
+// commonVerbs are verbs that commit subjects commonly start with
+var commonVerbs = []string{
+	"add", "adjust", "allow", "apply", "avoid", "bump", "change", "clean", "convert", "correct",
+	"create", "delete", "deprecate", "disable", "document", "drop", "enable", "ensure", "expose",
+	"extract", "fix", "format", "handle", "hide", "implement", "improve", "increase", "initialize",
+	"introduce", "load", "make", "merge", "migrate", "move", "optimize", "parse", "prevent", "print",
+	"reduce", "refactor", "release", "remove", "rename", "replace", "resolve", "restore", "return",
+	"revert", "rewrite", "save", "set", "show", "simplify", "skip", "split", "stop", "strip",
+	"support", "switch", "test", "tweak", "update", "upgrade", "use", "validate", "wrap", "write",
+}
+
+// irregularForms are non-imperative forms that the suffix rules don't produce
+var irregularForms = map[string]string{
+	"made": "make", "wrote": "write", "written": "write", "rewrote": "rewrite", "rewritten": "rewrite",
+	"hid": "hide", "hidden": "hide", "shown": "show",
+}
+
+// verbForms maps the past tense, third person and -ing forms of the common
+// verbs to their imperative form
+var verbForms = buildVerbForms()
+
+func buildVerbForms() map[string]string {
+	forms := make(map[string]string)
+	for _, verb := range commonVerbs {
+		for _, form := range inflect(verb) {
+			if form != verb {
+				forms[form] = verb
+			}
+		}
+	}
+	for form, verb := range irregularForms {
+		forms[form] = verb
+	}
+	return forms
+}
+
+// inflect returns the third person, past tense and -ing forms of a regular verb
+func inflect(verb string) []string {
+	last := verb[len(verb)-1]
+	stem := verb
+	if isDoubled(verb) {
+		stem = verb + string(last)
+	}
+
+	switch {
+	case strings.HasSuffix(verb, "e"):
+		base := strings.TrimSuffix(verb, "e")
+		return []string{verb + "s", verb + "d", base + "ing"}
+	case strings.HasSuffix(verb, "y") && !strings.ContainsRune("aeiou", rune(verb[len(verb)-2])):
+		base := strings.TrimSuffix(verb, "y")
+		return []string{base + "ies", base + "ied", verb + "ing"}
+	case strings.HasSuffix(verb, "x") || strings.HasSuffix(verb, "sh") || strings.HasSuffix(verb, "ch") || strings.HasSuffix(verb, "s"):
+		return []string{verb + "es", verb + "ed", verb + "ing"}
+	default:
+		return []string{verb + "s", stem + "ed", stem + "ing"}
+	}
+}
+
+// isDoubled reports whether the verb doubles its last consonant, e.g. drop, dropped
+func isDoubled(verb string) bool {
+	switch verb {
+	case "drop", "set", "skip", "split", "stop", "strip", "wrap":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package lint
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// listItem matches the marker of a list item, e.g. "- ", "* " or "1. "
+var listItem = regexp.MustCompile(`^\s{0,3}(?:[-*+]|\d+[.)])\s+`)
+
+// WrapBody hard-wraps the paragraphs of the message body at width. The
+// subject, blank lines, indented code, and trailers in the last paragraph are
+// kept as they are; list items are wrapped with their continuation lines
+// indented under the text. Words longer than width, like URLs, are not broken.
+func WrapBody(message string, width int) string {
+	lines := strings.Split(message, "\n")
+	if len(lines) < 2 || width <= 0 {
+		return message
+	}
+
+	// Trailers are only recognized in the last paragraph, like git does
+	lastParagraph := len(lines)
+	for lastParagraph > 1 && strings.TrimSpace(lines[lastParagraph-1]) == "" {
+		lastParagraph--
+	}
+	for lastParagraph > 1 && strings.TrimSpace(lines[lastParagraph-1]) != "" {
+		lastParagraph--
+	}
+
+	out := []string{lines[0]}
+	var words []string
+	var prefix, indent string
+	flush := func() {
+		if len(words) > 0 {
+			out = append(out, fill(words, prefix, indent, width)...)
+		}
+		words, prefix, indent = nil, "", ""
+	}
+
+	for i, line := range lines[1:] {
+		switch {
+		case strings.TrimSpace(line) == "":
+			flush()
+			out = append(out, line)
+		case i+1 >= lastParagraph && trailer.MatchString(line):
+			flush()
+			out = append(out, line)
+		case listItem.MatchString(line):
+			flush()
+			prefix = listItem.FindString(line)
+			indent = strings.Repeat(" ", utf8.RuneCountInString(prefix))
+			words = strings.Fields(line[len(prefix):])
+		case indent != "" && strings.HasPrefix(line, indent) && !strings.HasPrefix(line, indent+"    "):
+			// Continuation of a list item
+			words = append(words, strings.Fields(line)...)
+		case strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "    "):
+			flush()
+			out = append(out, line)
+		default:
+			if indent != "" {
+				// Text after a list item starts a new paragraph
+				flush()
+			}
+			words = append(words, strings.Fields(line)...)
+		}
+	}
+	flush()
+
+	return strings.Join(out, "\n")
+}
+
+// fill lays out words in lines of at most width characters. The first line
+// starts with prefix, the following ones with indent.
+func fill(words []string, prefix, indent string, width int) []string {
+	var lines []string
+	line := prefix
+	n := utf8.RuneCountInString(prefix)
+	start := n
+	for _, word := range words {
+		w := utf8.RuneCountInString(word)
+		if n > start && n+1+w > width {
+			lines = append(lines, line)
+			line, n = indent, utf8.RuneCountInString(indent)
+			start = n
+		}
+		if n > start {
+			line += " "
+			n++
+		}
+		line += word
+		n += w
+	}
+	return append(lines, line)
+}
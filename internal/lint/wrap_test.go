@@ -0,0 +1,122 @@
+package lint
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestWrapBody(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		width   int
+		want    string
+	}{
+		{
+			name:    "paragraph",
+			message: "fix: x\n\nthe quick brown fox jumps over the lazy dog",
+			width:   20,
+			want:    "fix: x\n\nthe quick brown fox\njumps over the lazy\ndog",
+		},
+		{
+			name:    "rewraps short lines",
+			message: "fix: x\n\nthe quick\nbrown fox\njumps",
+			width:   40,
+			want:    "fix: x\n\nthe quick brown fox jumps",
+		},
+		{
+			name:    "subject is never wrapped",
+			message: "feat: a subject line that is much longer than the width",
+			width:   10,
+			want:    "feat: a subject line that is much longer than the width",
+		},
+		{
+			name:    "paragraphs stay apart",
+			message: "fix: x\n\none two three\n\nfour five six",
+			width:   8,
+			want:    "fix: x\n\none two\nthree\n\nfour\nfive six",
+		},
+		{
+			name:    "list items",
+			message: "fix: x\n\n- first item that wraps\n- second\n1. numbered item wraps too",
+			width:   16,
+			want:    "fix: x\n\n- first item\n  that wraps\n- second\n1. numbered item\n   wraps too",
+		},
+		{
+			name:    "list item continuation",
+			message: "fix: x\n\n- one two\n  three four five",
+			width:   40,
+			want:    "fix: x\n\n- one two three four five",
+		},
+		{
+			name:    "text after a list",
+			message: "fix: x\n\n- item\nplain text",
+			width:   40,
+			want:    "fix: x\n\n- item\nplain text",
+		},
+		{
+			name:    "indented code",
+			message: "fix: x\n\nrun it:\n\n    go test ./... -run TestWrapBody -count=1\n\tmake all lint test",
+			width:   20,
+			want:    "fix: x\n\nrun it:\n\n    go test ./... -run TestWrapBody -count=1\n\tmake all lint test",
+		},
+		{
+			name:    "long words",
+			message: "fix: x\n\nsee https://example.com/a/very/long/url for details",
+			width:   20,
+			want:    "fix: x\n\nsee\nhttps://example.com/a/very/long/url\nfor details",
+		},
+		{
+			name:    "trailers",
+			message: "fix: x\n\nbody text here\n\nSigned-off-by: A Very Long Name <someone@example.com>\nRefs: PROJ-1",
+			width:   20,
+			want:    "fix: x\n\nbody text here\n\nSigned-off-by: A Very Long Name <someone@example.com>\nRefs: PROJ-1",
+		},
+		{
+			name:    "trailer-like lines in the body",
+			message: "fix: x\n\nNote: this line is long enough to wrap\n\nRefs: PROJ-1",
+			width:   20,
+			want:    "fix: x\n\nNote: this line is\nlong enough to wrap\n\nRefs: PROJ-1",
+		},
+		{
+			name:    "no body",
+			message: "fix: x",
+			width:   20,
+			want:    "fix: x",
+		},
+		{
+			name:    "disabled",
+			message: "fix: x\n\nthe quick brown fox jumps over the lazy dog",
+			width:   0,
+			want:    "fix: x\n\nthe quick brown fox jumps over the lazy dog",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := WrapBody(tt.message, tt.width)
+			if got != tt.want {
+				t.Errorf("WrapBody() =\n%s\nwant\n%s", got, tt.want)
+			}
+			if again := WrapBody(got, tt.width); again != got {
+				t.Errorf("wrapping again changed the message:\n%s", again)
+			}
+		})
+	}
+}
+
+func TestWrapBodyPassesLint(t *testing.T) {
+	message := "feat: add wrapping\n\n" + strings.Repeat("Lorem ipsum dolor sit amet, consectetur adipiscing elit. ", 8)
+	wrapped := WrapBody(message, 72)
+
+	cfg := DefaultConfig()
+	if violations := Lint(wrapped, cfg); len(violations) > 0 {
+		t.Errorf("wrapped message has violations %v:\n%s", violations, wrapped)
+	}
+	for _, line := range strings.Split(wrapped, "\n") {
+		if utf8.RuneCountInString(line) > 72 {
+			t.Errorf("line longer than 72 characters: %q", line)
+		}
+	}
+}
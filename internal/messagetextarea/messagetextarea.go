@@ -1,10 +1,20 @@
 package messagetextarea
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"slices"
 	"strings"
 
+	"github.com/hamzabow/co/internal/diffview"
+	"github.com/hamzabow/co/internal/editor"
+	"github.com/hamzabow/co/internal/lint"
+
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -16,9 +26,26 @@ const (
 	ResultCommit
 )
 
-// func MessageTextArea(msg string) string {
-func MessageTextArea(msg string) (string, CommitResult) {
-	p := tea.NewProgram(initialModel(msg))
+// Options configures the actions available in the editor
+type Options struct {
+	// Lint, when set, shows the rules the message breaks as it is edited
+	Lint *lint.Config
+	// Regenerate, when set, replaces the message with a new one on Ctrl+R
+	Regenerate func(ctx context.Context) (string, error)
+	// Refine, when set, revises the message following an instruction typed after Ctrl+G
+	Refine func(ctx context.Context, message, instruction string) (string, error)
+	// Diff, when set, is the staged diff that Ctrl+O shows next to the message
+	Diff string
+	// SubjectLimit is the subject length the status line counts against (0 hides the count)
+	SubjectLimit int
+	// WrapWidth is the width Alt+Q hard-wraps the body at (0 disables wrapping)
+	WrapWidth int
+}
+
+// MessageTextArea lets the user edit the message before committing. Messages
+// replaced by regenerating or refining are kept in a history the user can step through.
+func MessageTextArea(msg string, opts Options) (string, CommitResult) {
+	p := tea.NewProgram(initialModel(msg, opts))
 
 	m, err := p.Run()
 	if err != nil {
@@ -33,14 +60,57 @@ func MessageTextArea(msg string) (string, CommitResult) {
 
 type errMsg error
 
+// generatedMsg carries the message produced by regenerating or refining
+type generatedMsg struct {
+	id      int
+	message string
+	err     error
+}
+
+// editedMsg carries the message edited in the external editor
+type editedMsg struct {
+	message string
+	err     error
+}
+
 type model struct {
-	textarea textarea.Model
-	err      error
-	result   CommitResult
-	width    int
-	height   int
+	textarea   textarea.Model
+	err        error
+	result     CommitResult
+	width      int
+	height     int
+	opts       Options
+	violations []lint.Violation
+
+	// history holds the messages the user can step through, with the edits
+	// made to each; current is the index of the one being edited
+	history []string
+	current int
+
+	// instruction is the refine prompt, shown while refining is true
+	instruction textinput.Model
+	refining    bool
+
+	// diff is the staged diff pane, shown while showDiff is true
+	diff     diffview.Model
+	showDiff bool
+	// editorWidth is the outer width of the message box
+	editorWidth int
+
+	// busy describes the request in progress, empty when there is none
+	busy       string
+	spinner    spinner.Model
+	cancel     context.CancelFunc
+	generation int
 }
 
+// sideBySideWidth is the terminal width from which the diff is shown next to
+// the message rather than below it
+const sideBySideWidth = 100
+
+// maxShownViolations is the number of lint violations listed below the message
+const maxShownViolations = 5
+
 var (
 	// Title style with white text on purple background
 	titleStyle = lipgloss.NewStyle().
@@ -66,9 +136,22 @@ var (
 	helpStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#626262")).
 			MarginTop(1)
+
+	// Label style for requests in progress
+	busyStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#7D56F4")).
+			PaddingLeft(2)
+
+	// Lint violation styles
+	lintErrorStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FF5555")).
+			PaddingLeft(2)
+	lintWarningStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#FFB86C")).
+				PaddingLeft(2)
 )
 
-func initialModel(initialValue string) model {
+func initialModel(initialValue string, opts Options) model {
 	ti := textarea.New()
 	ti.SetValue(initialValue)
 	ti.Placeholder = "Commit message ..."
@@ -87,14 +170,167 @@ func initialModel(initialValue string) model {
 	ti.FocusedStyle.CursorLine = ti.FocusedStyle.CursorLine.Foreground(lipgloss.Color("#FAFAFA"))
 	ti.BlurredStyle.Text = ti.BlurredStyle.Text.Foreground(lipgloss.Color("#626262"))
 
-	return model{
+	instruction := textinput.New()
+	instruction.Prompt = "Refine: "
+	instruction.Placeholder = "e.g. shorter, mention the migration, use scope api"
+	instruction.PromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#7D56F4"))
+	instruction.TextStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FAFAFA"))
+
+	sp := spinner.New()
+	sp.Spinner = spinner.Dot
+	sp.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#7D56F4"))
+
+	m := model{
 		textarea: ti,
 		err:      nil,
 		result:   ResultCancel,
 		// We'll set proper width and height when we get a WindowSizeMsg
-		width:  80, // Default value, will be updated
-		height: 24, // Default value, will be updated
+		width:       80, // Default value, will be updated
+		height:      24, // Default value, will be updated
+		opts:        opts,
+		history:     []string{initialValue},
+		instruction: instruction,
+		spinner:     sp,
+		diff:        diffview.New(opts.Diff),
+	}
+	m.relint()
+	return m
+}
+
+// relint checks the message being edited against the lint rules
+func (m *model) relint() {
+	if m.opts.Lint != nil {
+		m.violations = lint.Lint(m.textarea.Value(), *m.opts.Lint)
+	}
+}
+
+// generate runs the request in the background; its result arrives as a generatedMsg
+func (m *model) generate(label string, run func(ctx context.Context) (string, error)) tea.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.generation++
+	m.busy = label
+	m.cancel = cancel
+	m.err = nil
+
+	id := m.generation
+	return tea.Batch(m.spinner.Tick, func() tea.Msg {
+		message, err := run(ctx)
+		return generatedMsg{id: id, message: message, err: err}
+	})
+}
+
+// stopGenerating cancels the request in progress, if any
+func (m *model) stopGenerating() {
+	if m.cancel != nil {
+		m.cancel()
+	}
+	m.busy = ""
+	m.cancel = nil
+}
+
+// show loads the message at index i of the history, keeping the edits made to the current one
+func (m *model) show(i int) {
+	m.history[m.current] = m.textarea.Value()
+	m.current = i
+	m.textarea.SetValue(m.history[i])
+	m.relint()
+}
+
+// push adds a generated message to the end of the history and shows it
+func (m *model) push(message string) {
+	m.history[m.current] = m.textarea.Value()
+	m.history = append(m.history, message)
+	m.show(len(m.history) - 1)
+}
+
+// resize lays out the message box, and the diff pane when shown, for the terminal size
+func (m *model) resize() {
+	sideBySide := m.showDiff && m.width >= sideBySideWidth
+
+	// Responsive behavior: automatically adjust dimensions based on terminal size
+	// Calculate dynamic width for the textarea
+	// Leave some margin on both sides and account for borders
+	m.editorWidth = m.width - 4 // Account for container margin
+	if sideBySide {
+		m.editorWidth = (m.width - 4) / 2
+	}
+	textareaWidth := m.editorWidth - 2 // 4 for borders/padding, less the 2 of the box width
+	if textareaWidth < 20 {            // Minimum reasonable width
+		textareaWidth = 20
+	}
+
+	// Update the textarea width
+	m.textarea.SetWidth(textareaWidth)
+
+	// Make height dynamic - use about 60% of available terminal height
+	// but leave space for title and help text (about 5 lines)
+	textareaHeight := (m.height * 60 / 100) - 5
+	if m.showDiff && !sideBySide {
+		// The diff below takes most of the space
+		textareaHeight = (m.height * 35 / 100) - 5
+	}
+	if textareaHeight < 5 { // Minimum reasonable height
+		textareaHeight = 5
+	}
+	if textareaHeight > 30 { // Reasonable maximum height
+		textareaHeight = 30
+	}
+
+	// Update the textarea height
+	m.textarea.SetHeight(textareaHeight)
+
+	// The box adds 4 lines of border and padding around the textarea
+	if sideBySide {
+		m.diff.SetSize(m.width-m.editorWidth-5, textareaHeight+4)
+	} else {
+		m.diff.SetSize(m.width-2, max(m.height-textareaHeight-12, 5))
+	}
+}
+
+// toggleDiff shows or hides the diff pane, moving the focus to the message when hiding it
+func (m *model) toggleDiff() tea.Cmd {
+	m.showDiff = !m.showDiff
+	m.resize()
+	if !m.showDiff && m.diff.Focused() {
+		m.diff.Blur()
+		return m.textarea.Focus()
+	}
+	return nil
+}
+
+// switchFocus moves the focus between the message and the diff pane
+func (m *model) switchFocus() tea.Cmd {
+	if m.diff.Focused() {
+		m.diff.Blur()
+		return m.textarea.Focus()
 	}
+	m.textarea.Blur()
+	m.diff.Focus()
+	return nil
+}
+
+// openEditor suspends the view and opens the message in the editor git uses
+func (m *model) openEditor() tea.Cmd {
+	draft, err := editor.NewDraft(m.textarea.Value())
+	if err != nil {
+		m.err = err
+		return nil
+	}
+	cmd, err := editor.Command(draft.Path)
+	if err != nil {
+		draft.Remove()
+		m.err = err
+		return nil
+	}
+
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		defer draft.Remove()
+		if err != nil {
+			return editedMsg{err: fmt.Errorf("%w: %w", editor.ErrEditorFailed, err)}
+		}
+		message, err := draft.Read()
+		return editedMsg{message: message, err: err}
+	})
 }
 
 func (m model) Init() tea.Cmd {
@@ -116,44 +352,139 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 
-		// Responsive behavior: automatically adjust dimensions based on terminal size
-		// Calculate dynamic width for the textarea
-		// Leave some margin on both sides and account for borders
-		textareaWidth := m.width - 6 // 2 for container margin + 4 for borders/padding
-		if textareaWidth < 20 {      // Minimum reasonable width
-			textareaWidth = 20
+		m.resize()
+		return m, nil
+
+	case generatedMsg:
+		// Results of cancelled or superseded requests are dropped
+		if msg.id != m.generation || m.busy == "" {
+			return m, nil
+		}
+		m.stopGenerating()
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
 		}
+		m.push(msg.message)
+		return m, nil
 
-		// Update the textarea width
-		m.textarea.SetWidth(textareaWidth)
+	case editedMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		m.err = nil
+		m.textarea.SetValue(msg.message)
+		m.relint()
+		return m, nil
 
-		// Make height dynamic - use about 60% of available terminal height
-		// but leave space for title and help text (about 5 lines)
-		textareaHeight := (m.height * 60 / 100) - 5
-		if textareaHeight < 5 { // Minimum reasonable height
-			textareaHeight = 5
+	case spinner.TickMsg:
+		if m.busy == "" {
+			return m, nil
 		}
-		if textareaHeight > 30 { // Reasonable maximum height
-			textareaHeight = 30
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
+	case tea.KeyMsg:
+		if msg.Type == tea.KeyCtrlC {
+			m.stopGenerating()
+			m.result = ResultCancel
+			return m, tea.Quit
 		}
 
-		// Update the textarea height
-		m.textarea.SetHeight(textareaHeight)
+		// While a message is being generated, keys other than Esc are ignored
+		// so that edits aren't lost when it replaces the message
+		if m.busy != "" {
+			if msg.Type == tea.KeyEsc {
+				m.stopGenerating()
+			}
+			return m, nil
+		}
 
-		return m, nil
+		if m.refining {
+			switch msg.Type {
+			case tea.KeyEsc:
+				m.refining = false
+				m.instruction.Blur()
+				return m, m.textarea.Focus()
+			case tea.KeyEnter:
+				instruction := strings.TrimSpace(m.instruction.Value())
+				if instruction == "" {
+					return m, nil
+				}
+				m.refining = false
+				m.instruction.Blur()
+				m.instruction.Reset()
+				message := m.textarea.Value()
+				return m, tea.Batch(m.textarea.Focus(), m.generate("Refining", func(ctx context.Context) (string, error) {
+					return m.opts.Refine(ctx, message, instruction)
+				}))
+			}
+			m.instruction, cmd = m.instruction.Update(msg)
+			return m, cmd
+		}
+
+		switch msg.String() {
+		case "ctrl+o":
+			if m.opts.Diff != "" {
+				return m, m.toggleDiff()
+			}
+			return m, nil
+		case "tab":
+			if m.showDiff {
+				return m, m.switchFocus()
+			}
+		case "ctrl+y":
+			m.result = ResultCommit
+			return m, tea.Quit
+		}
+
+		if m.diff.Focused() {
+			if msg.Type == tea.KeyEsc {
+				return m, m.switchFocus()
+			}
+			m.diff, cmd = m.diff.Update(msg)
+			return m, cmd
+		}
+
+		switch msg.String() {
+		case "ctrl+r":
+			if m.opts.Regenerate != nil {
+				return m, m.generate("Regenerating", m.opts.Regenerate)
+			}
+			return m, nil
+		case "ctrl+g":
+			if m.opts.Refine != nil {
+				m.refining = true
+				m.textarea.Blur()
+				return m, m.instruction.Focus()
+			}
+			return m, nil
+		case "alt+e":
+			return m, m.openEditor()
+		case "alt+q":
+			if m.opts.WrapWidth > 0 {
+				m.textarea.SetValue(lint.WrapBody(m.textarea.Value(), m.opts.WrapWidth))
+				m.relint()
+			}
+			return m, nil
+		case "alt+up":
+			if m.current > 0 {
+				m.show(m.current - 1)
+			}
+			return m, nil
+		case "alt+down":
+			if m.current < len(m.history)-1 {
+				m.show(m.current + 1)
+			}
+			return m, nil
+		}
 
-	case tea.KeyMsg:
 		switch msg.Type {
 		case tea.KeyEsc:
 			if m.textarea.Focused() {
 				m.textarea.Blur()
 			}
-		case tea.KeyCtrlC:
-			m.result = ResultCancel
-			return m, tea.Quit
-		case tea.KeyCtrlY: // Use Ctrl+Y as the commit shortcut
-			m.result = ResultCommit
-			return m, tea.Quit
 		default:
 			if !m.textarea.Focused() {
 				cmd = m.textarea.Focus()
@@ -169,9 +500,19 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	m.textarea, cmd = m.textarea.Update(msg)
 	cmds = append(cmds, cmd)
+	m.relint()
 	return m, tea.Batch(cmds...)
 }
 
+// sortedViolations returns the lint violations with errors before warnings
+func (m model) sortedViolations() []lint.Violation {
+	sorted := slices.Clone(m.violations)
+	slices.SortStableFunc(sorted, func(a, b lint.Violation) int {
+		return int(b.Severity) - int(a.Severity)
+	})
+	return sorted
+}
+
 func (m model) View() string {
 	var view strings.Builder
 
@@ -179,18 +520,75 @@ func (m model) View() string {
 	view.WriteString("\n\n")
 
 	// Dynamically set the width of the input box style based on terminal width
-	dynamicInputBoxStyle := inputBoxStyle.Width(m.width - 4) // Account for container margin
+	dynamicInputBoxStyle := inputBoxStyle.Width(m.editorWidth)
 
 	// Wrap the textarea in the dynamicInputBoxStyle
-	view.WriteString(dynamicInputBoxStyle.Render(m.textarea.View()))
+	editor := dynamicInputBoxStyle.Render(m.textarea.View())
+	switch {
+	case m.showDiff && m.width >= sideBySideWidth:
+		view.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, editor, " ", m.diff.View()))
+	case m.showDiff:
+		view.WriteString(editor + "\n" + m.diff.View())
+	default:
+		view.WriteString(editor)
+	}
 	view.WriteString("\n")
 
-	// Create a more helpful instruction line
-	helpText := "  Ctrl+C to quit, Ctrl+Y to commit"
-	if len(m.textarea.Value()) > 0 {
-		helpText += " | ↑/↓ arrows to scroll"
+	if status := m.statusLine(); status != "" {
+		view.WriteString(status + "\n")
+	}
+
+	// List the broken lint rules, errors first
+	for i, v := range m.sortedViolations() {
+		if i == maxShownViolations {
+			view.WriteString(helpStyle.UnsetMarginTop().PaddingLeft(2).Render(fmt.Sprintf("and %d more", len(m.violations)-i)) + "\n")
+			break
+		}
+		style, mark := lintWarningStyle, "!"
+		if v.Severity == lint.Error {
+			style, mark = lintErrorStyle, "✗"
+		}
+		view.WriteString(style.Render(fmt.Sprintf("%s line %s", mark, v)) + "\n")
+	}
+
+	if m.err != nil && !errors.Is(m.err, context.Canceled) {
+		view.WriteString(lintErrorStyle.Render("✗ "+m.err.Error()) + "\n")
+	}
+
+	// Long help lines wrap within the view rather than at the terminal edge
+	help := helpStyle.PaddingLeft(2).Width(max(m.width-2, 20))
+	switch {
+	case m.busy != "":
+		view.WriteString(busyStyle.Render(m.spinner.View()+" "+m.busy+"...") + "\n")
+		view.WriteString(help.Render("Esc to stop, Ctrl+C to quit"))
+	case m.refining:
+		view.WriteString("  " + m.instruction.View() + "\n")
+		view.WriteString(help.Render("Enter to refine the message, Esc to go back"))
+	case m.diff.Focused():
+		view.WriteString(help.Render("↑/↓ to scroll, n/p for the next/previous file, Tab to edit the message, Ctrl+O to hide the diff, Ctrl+Y to commit"))
+	default:
+		// Create a more helpful instruction line
+		helpText := "Ctrl+C to quit, Ctrl+Y to commit"
+		if len(m.textarea.Value()) > 0 {
+			helpText += " | ↑/↓ arrows to scroll"
+		}
+		helpText += " | Alt+E for your editor"
+		if m.opts.Regenerate != nil {
+			helpText += " | Ctrl+R to regenerate"
+		}
+		if m.opts.Refine != nil {
+			helpText += ", Ctrl+G to refine"
+		}
+		if m.showDiff {
+			helpText += " | Tab to browse the diff, Ctrl+O to hide it"
+		} else if m.opts.Diff != "" {
+			helpText += " | Ctrl+O to show the diff"
+		}
+		if len(m.history) > 1 {
+			helpText += fmt.Sprintf(" | Alt+↑/↓ for history (%d/%d)", m.current+1, len(m.history))
+		}
+		view.WriteString(help.Render(helpText))
 	}
-	view.WriteString(helpStyle.Render(helpText))
 
 	// Apply container style to the entire view
 	return containerStyle.Render(view.String())
@@ -0,0 +1,86 @@
+package messagetextarea
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxShownOverflow is the number of characters past the subject limit shown in the status line
+const maxShownOverflow = 24
+
+var (
+	// Status line styles
+	statusStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#626262"))
+	statusWarningStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#FFB86C"))
+	statusErrorStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#FF5555"))
+	overflowStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FAFAFA")).
+			Background(lipgloss.Color("#FF5555"))
+)
+
+// statusLine describes the structure of the message being edited: the
+// subject length, a missing blank line after it, and body lines that need wrapping
+func (m model) statusLine() string {
+	lines := strings.Split(m.textarea.Value(), "\n")
+	subject := []rune(lines[0])
+
+	var parts []string
+	if limit := m.opts.SubjectLimit; limit > 0 {
+		style := statusStyle
+		if len(subject) > limit {
+			style = statusWarningStyle
+		}
+		if hard := m.subjectMaxLength(); hard > 0 && len(subject) > hard {
+			style = statusErrorStyle
+		}
+		part := style.Render(fmt.Sprintf("Subject %d/%d", len(subject), limit))
+
+		// Show the end of the subject with the characters past the limit highlighted
+		if len(subject) > limit {
+			overflow := subject[limit:]
+			if len(overflow) > maxShownOverflow {
+				overflow = append(overflow[:maxShownOverflow-1], '…')
+			}
+			part += " " + statusStyle.Render("…"+string(subject[max(limit-8, 0):limit])) + overflowStyle.Render(string(overflow))
+		}
+		parts = append(parts, part)
+	}
+
+	if len(lines) > 1 && strings.TrimSpace(lines[1]) != "" {
+		parts = append(parts, statusWarningStyle.Render("no blank line after the subject"))
+	}
+
+	if width := m.opts.WrapWidth; width > 0 && len(lines) > 1 {
+		long := 0
+		for _, line := range lines[1:] {
+			if utf8.RuneCountInString(line) > width {
+				long++
+			}
+		}
+		switch {
+		case long == 1:
+			parts = append(parts, statusWarningStyle.Render(fmt.Sprintf("1 body line over %d, Alt+Q to wrap", width)))
+		case long > 1:
+			parts = append(parts, statusWarningStyle.Render(fmt.Sprintf("%d body lines over %d, Alt+Q to wrap", long, width)))
+		}
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+	return lipgloss.NewStyle().PaddingLeft(2).Width(max(m.width-2, 20)).Render(strings.Join(parts, statusStyle.Render(" · ")))
+}
+
+// subjectMaxLength returns the subject length the lint rules allow, 0 when unlimited
+func (m model) subjectMaxLength() int {
+	if m.opts.Lint == nil {
+		return 0
+	}
+	return m.opts.Lint.SubjectMaxLength
+}
@@ -1,14 +1,40 @@
 package prompts
 
+// languageInstruction is appended to every built-in prompt so that the
+// configured language is honored regardless of the format.
+const languageInstruction = "{{if .Language}}\n\nWrite the commit message in {{.Language}}.{{end}}"
+
+// scopeInstruction restricts the scope to the configured list for formats that have one
+const scopeInstruction = "{{if .Scopes}}\n\nIf you include a scope, it must be one of: " +
+	"{{range $i, $scope := .Scopes}}{{if $i}}, {{end}}{{$scope}}{{end}}.{{end}}"
+
+// contextInstruction is appended to every built-in prompt to give the model
+// the repository context gathered alongside the diff. The staged files are
+// only listed without the stat, which already names them.
+const contextInstruction = "{{if or (and .Branch (ne .Branch \"HEAD\")) .Stat .Files .Renamed .Deleted .RecentCommits}}\n\n### Context\n" +
+	"{{if and .Branch (ne .Branch \"HEAD\")}}\nCurrent branch: {{.Branch}}\n{{end}}" +
+	"{{if .Stat}}\nFiles changed (output of `git diff --staged --stat`):\n```\n{{.Stat}}\n```\n" +
+	"{{else if .Files}}\nStaged files:\n{{range .Files}}- {{.}}\n{{end}}{{end}}" +
+	"{{if .Renamed}}\nRenamed files:\n{{range .Renamed}}- {{.From}} -> {{.To}}\n{{end}}{{end}}" +
+	"{{if .Deleted}}\nDeleted files:\n{{range .Deleted}}- {{.}}\n{{end}}{{end}}" +
+	"{{if .RecentCommits}}\nRecent commit subjects in this repository, newest first. Match their style and reuse their scopes where they fit:\n" +
+	"{{range .RecentCommits}}- {{.}}\n{{end}}{{end}}{{end}}" + examplesInstruction
+
+// examplesInstruction shows past commit messages of the repository as examples
+const examplesInstruction = "{{if .Examples}}\n\n### Examples from this repository\n" +
+	"Past commit messages of this repository follow. Write the message in the same style" +
+	" (structure, tone, capitalization, length and use of a body), even where it differs from any generic examples above:\n" +
+	"{{range .Examples}}\n```\n{{.}}\n```\n{{end}}{{end}}"
+
 var SimplePrompt = "Generate a concise and clear commit message describing " +
-	"the following changes (output of `git diff`):\n```\n%s\n```\n\nEnsure the message is concise and meaningful. Return only the commit message, no extra text, and don't wrap the commit message with code blocks."
+	"the following changes (output of `git diff`):\n```\n{{.Diff}}\n```\n\nEnsure the message is concise and meaningful. Return only the commit message, no extra text, and don't wrap the commit message with code blocks." + contextInstruction + languageInstruction
 
 var ShortConventionalCommitsPrompt = "Generate a concise and clear commit message that follows" +
 	" the **Conventional Commits** format. The commit message should" +
 	" describe the following changes (output of command `git diff --staged`):" +
-	"\n```\n%s\n```\n\n" +
+	"\n```\n{{.Diff}}\n```\n\n" +
 	"Ensure the message is concise and meaningful. Return only the commit message," +
-	" no extra text, and don't wrap the commit message with code blocks."
+	" no extra text, and don't wrap the commit message with code blocks." + contextInstruction + scopeInstruction + languageInstruction
 
 var LongConventionalCommitsPrompt = `Please generate a commit message following the **Conventional Commits** format.
 
@@ -85,7 +111,7 @@ BREAKING CHANGE: Authentication API now requires JWT tokens instead of session I
 
 ### **Given the following staged git diff, generate a commit message that strictly follows this specification:**
 
-` + "```\n%s\n```\n\n" + `Ensure the message is concise and meaningful. Return only the commit message, no extra text, and don't wrap the commit message with code blocks.`
+` + "```\n{{.Diff}}\n```\n\n" + `Ensure the message is concise and meaningful. Return only the commit message, no extra text, and don't wrap the commit message with code blocks.` + contextInstruction + scopeInstruction + languageInstruction
 
 var GitmojiPrompt = "Generate a commit message that follows the **Gitmoji** " +
 	"specification using the **Unicode format** for emojis.\n\n" +
@@ -107,7 +133,7 @@ var GitmojiPrompt = "Generate a commit message that follows the **Gitmoji** " +
 	"Ensure the commit message follows this format strictly. " +
 	"Return only the commit message, no extra text, and don't wrap it with code blocks.\n\n" +
 	"The commit message should describe the following changes (output of command `git diff --staged`):" +
-	"\n```\n%s\n```"
+	"\n```\n{{.Diff}}\n```" + contextInstruction + scopeInstruction + languageInstruction
 
 var GitmojiShortcodePrompt = "Generate a commit message that follows the **Gitmoji** " +
 	"specification using the **shortcode format** for emojis.\n\n" +
@@ -129,4 +155,4 @@ var GitmojiShortcodePrompt = "Generate a commit message that follows the **Gitmo
 	"Ensure the commit message follows this format strictly. " +
 	"Return only the commit message, no extra text, and don't wrap it with code blocks.\n\n" +
 	"The commit message should describe the following changes (output of command `git diff --staged`):" +
-	"\n```\n%s\n```"
+	"\n```\n{{.Diff}}\n```" + contextInstruction + scopeInstruction + languageInstruction
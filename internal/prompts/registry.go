@@ -0,0 +1,169 @@
+package prompts
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// Names of the built-in commit message formats
+const (
+	FormatConventional      = "conventional"
+	FormatConventionalShort = "conventional-short"
+	FormatGitmoji           = "gitmoji"
+	FormatGitmojiShortcode  = "gitmoji-shortcode"
+	FormatSimple            = "simple"
+)
+
+// DefaultFormat is used when no format is selected by flag or config
+const DefaultFormat = FormatConventional
+
+// TemplateExt is the file extension of custom prompt templates
+const TemplateExt = ".tmpl"
+
+// ErrUnknownFormat is returned when a format name isn't registered
+var ErrUnknownFormat = errors.New("unknown commit message format")
+
+// builtins maps the built-in format names to their prompt templates
+var builtins = map[string]string{
+	FormatConventional:      LongConventionalCommitsPrompt,
+	FormatConventionalShort: ShortConventionalCommitsPrompt,
+	FormatGitmoji:           GitmojiPrompt,
+	FormatGitmojiShortcode:  GitmojiShortcodePrompt,
+	FormatSimple:            SimplePrompt,
+}
+
+// Data holds the variables available to prompt templates
+type Data struct {
+	// Diff is the staged diff (output of git diff --staged)
+	Diff string
+	// Branch is the name of the current branch
+	Branch string
+	// Files lists the paths of the staged files
+	Files []string
+	// Stat is the output of git diff --staged --stat
+	Stat string
+	// Renamed lists the staged renames
+	Renamed []Rename
+	// Deleted lists the paths of the staged deletions
+	Deleted []string
+	// RecentCommits holds the subjects of the most recent commits, newest first
+	RecentCommits []string
+	// Examples holds full past commit messages showing the repository's conventions
+	Examples []string
+	// Scopes lists the allowed commit scopes (empty means any scope)
+	Scopes []string
+	// Language is the natural language the message should be written in (empty means unspecified)
+	Language string
+}
+
+// Rename is a file moved from one path to another
+type Rename struct {
+	From, To string
+}
+
+// Registry holds prompt templates by name. Built-in formats are always present
+// and may be overridden by custom templates loaded from disk.
+type Registry struct {
+	templates map[string]*template.Template
+	sources   map[string]string
+}
+
+// Formats returns the names of the built-in formats in a stable order
+func Formats() []string {
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewRegistry creates a registry containing the built-in formats
+func NewRegistry() *Registry {
+	r := &Registry{
+		templates: make(map[string]*template.Template),
+		sources:   make(map[string]string),
+	}
+	for name, text := range builtins {
+		// Built-in templates are known to be valid
+		r.templates[name] = template.Must(template.New(name).Parse(text))
+		r.sources[name] = "built-in"
+	}
+	return r
+}
+
+// LoadDir adds every *.tmpl file in dir to the registry, named after the file
+// without its extension. Templates loaded later replace earlier ones with the
+// same name. A missing directory is not an error.
+func (r *Registry) LoadDir(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+TemplateExt))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		text, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		name := strings.TrimSuffix(filepath.Base(path), TemplateExt)
+		tmpl, err := template.New(name).Parse(string(text))
+		if err != nil {
+			return fmt.Errorf("invalid prompt template %s: %w", path, err)
+		}
+
+		r.templates[name] = tmpl
+		r.sources[name] = path
+	}
+
+	return nil
+}
+
+// Names returns the names of all registered templates in a stable order
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.templates))
+	for name := range r.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Has reports whether a template is registered under name
+func (r *Registry) Has(name string) bool {
+	_, ok := r.templates[name]
+	return ok
+}
+
+// Validate returns ErrUnknownFormat, listing the available names, when no template is registered under name
+func (r *Registry) Validate(name string) error {
+	if r.Has(name) {
+		return nil
+	}
+	return fmt.Errorf("%w: %q (available: %s)", ErrUnknownFormat, name, strings.Join(r.Names(), ", "))
+}
+
+// Source returns where the named template came from ("built-in" or a file path)
+func (r *Registry) Source(name string) string {
+	return r.sources[name]
+}
+
+// Render executes the named template with the given data
+func (r *Registry) Render(name string, data Data) (string, error) {
+	if err := r.Validate(name); err != nil {
+		return "", err
+	}
+	tmpl := r.templates[name]
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt %q: %w", name, err)
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,215 @@
+package prompts
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// writeTemplates writes the named templates into a new directory
+func writeTemplates(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, text := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestFormats(t *testing.T) {
+	want := []string{FormatConventional, FormatConventionalShort, FormatGitmoji, FormatGitmojiShortcode, FormatSimple}
+	if got := Formats(); !slices.Equal(got, want) {
+		t.Errorf("Formats() = %v, want %v", got, want)
+	}
+	if !slices.Contains(Formats(), DefaultFormat) {
+		t.Errorf("default format %q isn't built in", DefaultFormat)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	r := NewRegistry()
+
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{FormatConventional, nil},
+		{FormatGitmojiShortcode, nil},
+		{FormatSimple, nil},
+		{"", ErrUnknownFormat},
+		{"Simple", ErrUnknownFormat},
+		{"conventional.tmpl", ErrUnknownFormat},
+	}
+
+	for _, tt := range tests {
+		err := r.Validate(tt.name)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("Validate(%q) = %v, want %v", tt.name, err, tt.err)
+		}
+		if r.Has(tt.name) != (tt.err == nil) {
+			t.Errorf("Has(%q) = %v, want %v", tt.name, r.Has(tt.name), tt.err == nil)
+		}
+	}
+
+	// The error lists what is available
+	err := r.Validate("nope")
+	if !strings.Contains(err.Error(), `"nope"`) || !strings.Contains(err.Error(), strings.Join(Formats(), ", ")) {
+		t.Errorf("error = %q, want the name and the available formats", err)
+	}
+}
+
+func TestRenderBuiltins(t *testing.T) {
+	r := NewRegistry()
+	data := Data{Diff: "diff --git a/x b/x\n+added line\n"}
+
+	for _, name := range Formats() {
+		t.Run(name, func(t *testing.T) {
+			if source := r.Source(name); source != "built-in" {
+				t.Errorf("Source() = %q, want built-in", source)
+			}
+			prompt, err := r.Render(name, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(prompt, data.Diff) {
+				t.Errorf("prompt doesn't contain the diff:\n%s", prompt)
+			}
+		})
+	}
+
+	if _, err := r.Render("nope", data); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Render(nope) = %v, want %v", err, ErrUnknownFormat)
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	user := writeTemplates(t, map[string]string{
+		"team.tmpl":   "user team {{.Diff}}",
+		"simple.tmpl": "user simple {{.Diff}}",
+		"notes.txt":   "not a template",
+	})
+	repo := writeTemplates(t, map[string]string{
+		"team.tmpl": "repo team {{.Diff}}",
+	})
+
+	r := NewRegistry()
+	for _, dir := range []string{user, repo, filepath.Join(user, "missing")} {
+		if err := r.LoadDir(dir); err != nil {
+			t.Fatalf("LoadDir(%s): %v", dir, err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		want   string
+		source string
+	}{
+		// Templates loaded later win
+		{"team", "repo team d", filepath.Join(repo, "team.tmpl")},
+		// Custom templates replace built-in ones
+		{FormatSimple, "user simple d", filepath.Join(user, "simple.tmpl")},
+		{FormatGitmoji, "", "built-in"},
+	}
+
+	for _, tt := range tests {
+		if source := r.Source(tt.name); source != tt.source {
+			t.Errorf("Source(%q) = %q, want %q", tt.name, source, tt.source)
+		}
+		if tt.want == "" {
+			continue
+		}
+		if got, err := r.Render(tt.name, Data{Diff: "d"}); err != nil || got != tt.want {
+			t.Errorf("Render(%q) = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+
+	if r.Has("notes") {
+		t.Error("files without the template extension must be ignored")
+	}
+	if want := append(Formats(), "team"); !slices.Equal(r.Names(), slices.Sorted(slices.Values(want))) {
+		t.Errorf("Names() = %v, want %v", r.Names(), want)
+	}
+}
+
+func TestLoadDirInvalidTemplate(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{"broken.tmpl": "{{.Diff"})
+
+	r := NewRegistry()
+	err := r.LoadDir(dir)
+	if err == nil || !strings.Contains(err.Error(), filepath.Join(dir, "broken.tmpl")) {
+		t.Errorf("LoadDir() = %v, want an error naming the file", err)
+	}
+	if r.Has("broken") {
+		t.Error("invalid template was registered")
+	}
+}
+
+func TestRenderVariables(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{
+		"vars.tmpl":    "{{.Branch}}|{{range .Files}}{{.}},{{end}}|{{range .Renamed}}{{.From}}>{{.To}}{{end}}|{{.Language}}|{{len .Scopes}}",
+		"missing.tmpl": "{{.Missing}}",
+	})
+	r := NewRegistry()
+	if err := r.LoadDir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	data := Data{
+		Branch:   "feature/x",
+		Files:    []string{"a.go", "b.go"},
+		Renamed:  []Rename{{From: "old.go", To: "new.go"}},
+		Language: "French",
+		Scopes:   []string{"api", "ui"},
+	}
+	if got, err := r.Render("vars", data); err != nil || got != "feature/x|a.go,b.go,|old.go>new.go|French|2" {
+		t.Errorf("Render(vars) = %q, %v", got, err)
+	}
+
+	// Unknown fields only fail when the template is executed
+	if _, err := r.Render("missing", data); err == nil || !strings.Contains(err.Error(), `"missing"`) {
+		t.Errorf("Render(missing) = %v, want an error naming the template", err)
+	}
+}
+
+func TestContextInstruction(t *testing.T) {
+	r := NewRegistry()
+
+	tests := []struct {
+		name    string
+		data    Data
+		want    []string
+		notWant []string
+	}{
+		{"no context", Data{Branch: "HEAD"}, nil, []string{"### Context", "Current branch"}},
+		{"branch", Data{Branch: "main"}, []string{"### Context", "Current branch: main"}, nil},
+		{"files without stat", Data{Files: []string{"a.go"}}, []string{"Staged files:\n- a.go\n"}, nil},
+		{"stat replaces files", Data{Stat: " a.go | 1 +", Files: []string{"a.go"}}, []string{" a.go | 1 +"}, []string{"Staged files"}},
+		{"renames and deletions", Data{Renamed: []Rename{{From: "x", To: "y"}}, Deleted: []string{"z"}}, []string{"- x -> y\n", "Deleted files:\n- z\n"}, nil},
+		{"examples", Data{Examples: []string{"fix: one"}}, []string{"### Examples from this repository", "```\nfix: one\n```"}, []string{"### Context"}},
+		{"language and scopes", Data{Language: "German", Scopes: []string{"api", "ui"}}, []string{"Write the commit message in German.", "must be one of: api, ui."}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prompt, err := r.Render(FormatConventional, tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(prompt, s) {
+					t.Errorf("prompt doesn't contain %q:\n%s", s, prompt)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(prompt, s) {
+					t.Errorf("prompt contains %q:\n%s", s, prompt)
+				}
+			}
+		})
+	}
+}
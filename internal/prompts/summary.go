@@ -0,0 +1,25 @@
+package prompts
+
+import (
+	"strings"
+	"text/template"
+)
+
+// ChunkSummaryPrompt asks for a summary of part of a diff too large to send at
+// once; the summaries are then used in place of the diff by the commit message prompt
+var ChunkSummaryPrompt = "The following is part of a large staged change (output of command `git diff --staged`)." +
+	" Summarize what changed in this part and why, as at most five short bullet points." +
+	" Mention the files, functions and types that matter, and leave out trivial details." +
+	" Return only the bullet points, in English.\n```\n{{.Diff}}\n```"
+
+// chunkSummaryTemplate is parsed once, the prompt is known to be valid
+var chunkSummaryTemplate = template.Must(template.New("chunk-summary").Parse(ChunkSummaryPrompt))
+
+// RenderChunkSummary renders the prompt summarizing a part of a diff
+func RenderChunkSummary(diff string) (string, error) {
+	var out strings.Builder
+	if err := chunkSummaryTemplate.Execute(&out, Data{Diff: diff}); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
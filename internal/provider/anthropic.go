@@ -0,0 +1,231 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// DefaultAnthropicModel is used when no model is configured for Anthropic
+	DefaultAnthropicModel = "claude-3-5-sonnet-latest"
+	// DefaultAnthropicBaseURL is the public Anthropic API endpoint
+	DefaultAnthropicBaseURL = "https://api.anthropic.com"
+
+	anthropicVersion   = "2023-06-01"
+	anthropicMaxTokens = 1024
+)
+
+// anthropicProvider talks to the Anthropic Messages API
+type anthropicProvider struct {
+	client      *http.Client
+	apiKey      string
+	model       string
+	baseURL     string
+	temperature *float64
+}
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicRequest struct {
+	Model       string             `json:"model"`
+	MaxTokens   int                `json:"max_tokens"`
+	Messages    []anthropicMessage `json:"messages"`
+	Temperature *float64           `json:"temperature,omitempty"`
+	Stream      bool               `json:"stream,omitempty"`
+}
+
+type anthropicResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+}
+
+// anthropicEvent is a server-sent event of a streamed Messages API response
+type anthropicEvent struct {
+	Type  string `json:"type"`
+	Delta struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"delta"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// anthropicErrorStatus maps the error types of stream error events to the
+// status the error has when returned before the stream starts
+var anthropicErrorStatus = map[string]int{
+	"invalid_request_error": http.StatusBadRequest,
+	"authentication_error":  http.StatusUnauthorized,
+	"permission_error":      http.StatusForbidden,
+	"not_found_error":       http.StatusNotFound,
+	"request_too_large":     http.StatusRequestEntityTooLarge,
+	"rate_limit_error":      http.StatusTooManyRequests,
+	"api_error":             http.StatusInternalServerError,
+	"overloaded_error":      529,
+}
+
+type anthropicModelsResponse struct {
+	Data []struct {
+		ID string `json:"id"`
+	} `json:"data"`
+	HasMore bool   `json:"has_more"`
+	LastID  string `json:"last_id"`
+}
+
+func newAnthropic(opts Options) *anthropicProvider {
+	model := opts.Model
+	if model == "" {
+		model = DefaultAnthropicModel
+	}
+	baseURL := opts.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultAnthropicBaseURL
+	}
+
+	return &anthropicProvider{
+		client:      opts.HTTPClient,
+		apiKey:      opts.APIKey,
+		model:       model,
+		baseURL:     baseURL,
+		temperature: opts.Temperature,
+	}
+}
+
+func (p *anthropicProvider) Name() string {
+	return Anthropic
+}
+
+func (p *anthropicProvider) Model() string {
+	return p.model
+}
+
+func (p *anthropicProvider) headers() map[string]string {
+	return map[string]string{
+		"x-api-key":         p.apiKey,
+		"anthropic-version": anthropicVersion,
+	}
+}
+
+func (p *anthropicProvider) Generate(ctx context.Context, req Request) (string, error) {
+	body := anthropicRequest{
+		Model:     p.model,
+		MaxTokens: anthropicMaxTokens,
+		Messages: []anthropicMessage{
+			{Role: "user", Content: req.Prompt},
+		},
+		Temperature: p.temperature,
+	}
+
+	if req.OnDelta != nil {
+		body.Stream = true
+		return p.stream(ctx, body, req.OnDelta)
+	}
+
+	var resp anthropicResponse
+	if err := doJSON(ctx, p.client, http.MethodPost, joinURL(p.baseURL, "/v1/messages"), p.headers(), body, &resp); err != nil {
+		return "", err
+	}
+
+	// The Messages API returns a list of content blocks; only text blocks carry the answer
+	var text strings.Builder
+	for _, block := range resp.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+
+	if text.Len() == 0 {
+		return "", ErrEmptyResponse
+	}
+	return text.String(), nil
+}
+
+// stream reads the response as server-sent events, reporting text deltas to onDelta
+func (p *anthropicProvider) stream(ctx context.Context, body anthropicRequest, onDelta func(string)) (string, error) {
+	url := joinURL(p.baseURL, "/v1/messages")
+	resp, err := send(ctx, p.client, http.MethodPost, url, p.headers(), body)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var text strings.Builder
+	err = scanLines(resp.Body, func(line string) error {
+		// Only data lines carry payloads; event names are repeated in the JSON type
+		data, ok := strings.CutPrefix(line, "data:")
+		if !ok {
+			return nil
+		}
+
+		var event anthropicEvent
+		if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &event); err != nil {
+			return err
+		}
+
+		switch event.Type {
+		case "content_block_delta":
+			if event.Delta.Type == "text_delta" && event.Delta.Text != "" {
+				text.WriteString(event.Delta.Text)
+				onDelta(event.Delta.Text)
+			}
+		case "error":
+			// Classified like the same error returned with a status, so that
+			// an overloaded or rate limited stream is retried
+			status, ok := anthropicErrorStatus[event.Error.Type]
+			if !ok {
+				return fmt.Errorf("%s: %s", event.Error.Type, event.Error.Message)
+			}
+			return &statusError{
+				method:     http.MethodPost,
+				url:        url,
+				statusCode: status,
+				status:     event.Error.Type,
+				message:    event.Error.Message,
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if text.Len() == 0 {
+		return "", ErrEmptyResponse
+	}
+	return text.String(), nil
+}
+
+func (p *anthropicProvider) ListModels(ctx context.Context) ([]string, error) {
+	var models []string
+	afterID := ""
+	for {
+		query := url.Values{"limit": {"1000"}}
+		if afterID != "" {
+			query.Set("after_id", afterID)
+		}
+
+		var resp anthropicModelsResponse
+		if err := doJSON(ctx, p.client, http.MethodGet, joinURL(p.baseURL, "/v1/models")+"?"+query.Encode(), p.headers(), nil, &resp); err != nil {
+			return nil, err
+		}
+		for _, model := range resp.Data {
+			models = append(models, model.ID)
+		}
+
+		// The endpoint is paginated by ID
+		if !resp.HasMore || resp.LastID == "" {
+			return models, nil
+		}
+		afterID = resp.LastID
+	}
+}
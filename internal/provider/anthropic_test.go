@@ -0,0 +1,133 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestAnthropicRequest(t *testing.T) {
+	temperature := 0.3
+	var got anthropicRequest
+	srv := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/messages" {
+			t.Errorf("got %s %s, want POST /v1/messages", r.Method, r.URL.Path)
+		}
+		for name, want := range map[string]string{
+			"x-api-key":         "test-key",
+			"anthropic-version": "2023-06-01",
+			"Content-Type":      "application/json",
+		} {
+			if v := r.Header.Get(name); v != want {
+				t.Errorf("header %s = %q, want %q", name, v, want)
+			}
+		}
+		if v := r.Header.Get("Authorization"); v != "" {
+			t.Errorf("unexpected Authorization header %q", v)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		fmt.Fprint(w, `{"content": [{"type": "text", "text": "feat: add "}, {"type": "tool_use"}, {"type": "text", "text": "anthropic"}]}`)
+	})
+
+	p, err := New(Anthropic, Options{APIKey: "test-key", BaseURL: srv.URL, Model: "claude-test", Temperature: &temperature})
+	if err != nil {
+		t.Fatal(err)
+	}
+	message, err := p.Generate(context.Background(), Request{Prompt: "the diff"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if message != "feat: add anthropic" {
+		t.Errorf("message = %q, want the text blocks joined", message)
+	}
+	if got.Model != "claude-test" || got.MaxTokens != anthropicMaxTokens || got.Stream {
+		t.Errorf("request = %+v", got)
+	}
+	if len(got.Messages) != 1 || got.Messages[0].Role != "user" || got.Messages[0].Content != "the diff" {
+		t.Errorf("messages = %+v, want the prompt as a single user message", got.Messages)
+	}
+	if got.Temperature == nil || *got.Temperature != temperature {
+		t.Errorf("temperature = %v, want %v", got.Temperature, temperature)
+	}
+}
+
+func TestAnthropicStream(t *testing.T) {
+	var stream bool
+	srv := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var body anthropicRequest
+		json.NewDecoder(r.Body).Decode(&body)
+		stream = body.Stream
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: message_start\ndata: {\"type\": \"message_start\"}\n\n")
+		fmt.Fprint(w, "event: content_block_delta\ndata: {\"type\": \"content_block_delta\", \"delta\": {\"type\": \"text_delta\", \"text\": \"fix: \"}}\n\n")
+		fmt.Fprint(w, "event: ping\ndata: {\"type\": \"ping\"}\n\n")
+		fmt.Fprint(w, "event: content_block_delta\ndata: {\"type\": \"content_block_delta\", \"delta\": {\"type\": \"text_delta\", \"text\": \"stream\"}}\n\n")
+		fmt.Fprint(w, "event: message_stop\ndata: {\"type\": \"message_stop\"}\n\n")
+	})
+
+	p, err := New(Anthropic, Options{APIKey: "test-key", BaseURL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var deltas []string
+	message, err := p.Generate(context.Background(), Request{Prompt: "diff", OnDelta: func(d string) { deltas = append(deltas, d) }})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !stream {
+		t.Error("request did not ask for a stream")
+	}
+	if message != "fix: stream" || len(deltas) != 2 {
+		t.Errorf("message = %q, deltas = %q", message, deltas)
+	}
+}
+
+func TestAnthropicStreamError(t *testing.T) {
+	srv := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "event: error\ndata: {\"type\": \"error\", \"error\": {\"type\": \"invalid_request_error\", \"message\": \"bad prompt\"}}\n\n")
+	})
+
+	p, err := New(Anthropic, Options{APIKey: "test-key", BaseURL: srv.URL, Retry: &RetryPolicy{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.Generate(context.Background(), Request{Prompt: "diff", OnDelta: func(string) {}})
+	if err == nil || !errors.Is(err, ErrRequestFailed) {
+		t.Errorf("err = %v, want %v", err, ErrRequestFailed)
+	}
+}
+
+func TestAnthropicEmptyResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		onDelta func(string)
+	}{
+		{"no text blocks", `{"content": [{"type": "tool_use"}]}`, nil},
+		{"empty stream", "event: message_stop\ndata: {\"type\": \"message_stop\"}\n\n", func(string) {}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, tt.body)
+			})
+
+			p, err := New(Anthropic, Options{APIKey: "test-key", BaseURL: srv.URL})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := p.Generate(context.Background(), Request{Prompt: "diff", OnDelta: tt.onDelta}); !errors.Is(err, ErrEmptyResponse) {
+				t.Errorf("err = %v, want %v", err, ErrEmptyResponse)
+			}
+		})
+	}
+}
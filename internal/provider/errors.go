@@ -0,0 +1,178 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openai/openai-go"
+)
+
+// Kinds of provider failures, matched with errors.Is against an *Error
+var (
+	ErrAuth          = errors.New("authentication failed")
+	ErrRateLimited   = errors.New("rate limited")
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	ErrContextLength = errors.New("context length exceeded")
+	ErrModelNotFound = errors.New("model not found")
+	ErrNetwork       = errors.New("network error")
+	ErrServer        = errors.New("server error")
+	ErrRequestFailed = errors.New("request failed")
+)
+
+// maxRetryAfterWait caps the delay a server can request through Retry-After
+const maxRetryAfterWait = 2 * time.Minute
+
+// Error describes a failed provider request
+type Error struct {
+	// Provider is the name of the provider that failed
+	Provider string
+	// Kind is one of the Err* sentinels above
+	Kind error
+	// StatusCode is the HTTP status code, or 0 for network errors
+	StatusCode int
+	// Message is the provider's own error message
+	Message string
+	// RetryAfter is the delay requested by the provider before retrying, if any
+	RetryAfter time.Duration
+	// Err is the underlying error
+	Err error
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("%s: %v", e.Provider, e.Kind)
+	if e.StatusCode != 0 {
+		msg += fmt.Sprintf(" (HTTP %d)", e.StatusCode)
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	} else if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap exposes both the kind and the underlying error to errors.Is and errors.As
+func (e *Error) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// Retryable reports whether repeating the request may succeed
+func (e *Error) Retryable() bool {
+	return e.Kind == ErrRateLimited || e.Kind == ErrServer || e.Kind == ErrNetwork
+}
+
+// statusError is returned by send for non-2xx responses before classification
+type statusError struct {
+	method     string
+	url        string
+	statusCode int
+	status     string
+	message    string
+	header     http.Header
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s %s: %s: %s", e.method, e.url, e.status, e.message)
+}
+
+// classify turns an error from a provider implementation into an *Error.
+// Context cancellation is passed through unchanged.
+func classify(providerName string, err error) error {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	var perr *Error
+	if errors.As(err, &perr) {
+		return err
+	}
+
+	e := &Error{Provider: providerName, Kind: ErrRequestFailed, Err: err}
+
+	var serr *statusError
+	var oerr *openai.Error
+	var nerr net.Error
+	switch {
+	case errors.As(err, &serr):
+		e.StatusCode = serr.statusCode
+		e.Message = serr.message
+		e.RetryAfter = parseRetryAfter(serr.header)
+	case errors.As(err, &oerr):
+		e.StatusCode = oerr.StatusCode
+		// The SDK decodes the body root, but OpenAI nests the details under "error"
+		e.Message = oerr.Message
+		if e.Message == "" {
+			e.Message = errorMessage([]byte(oerr.JSON.RawJSON()))
+		}
+		if oerr.Response != nil {
+			e.RetryAfter = parseRetryAfter(oerr.Response.Header)
+		}
+	case errors.As(err, &nerr) || errors.Is(err, io.ErrUnexpectedEOF):
+		// Truncated responses don't always surface as net.Error
+		e.Kind = ErrNetwork
+		return e
+	default:
+		return e
+	}
+
+	e.Kind = kindForStatus(e.StatusCode, strings.ToLower(e.Message))
+	return e
+}
+
+// kindForStatus maps an HTTP status and the lowercased error message to an error kind
+func kindForStatus(status int, message string) error {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return ErrAuth
+	case strings.Contains(message, "insufficient_quota") || strings.Contains(message, "quota") ||
+		strings.Contains(message, "credit balance"):
+		return ErrQuotaExceeded
+	case status == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case strings.Contains(message, "context_length_exceeded") || strings.Contains(message, "context length") ||
+		strings.Contains(message, "context window") || strings.Contains(message, "prompt is too long") ||
+		strings.Contains(message, "too many tokens") || status == http.StatusRequestEntityTooLarge:
+		return ErrContextLength
+	case status == http.StatusNotFound && strings.Contains(message, "model"):
+		return ErrModelNotFound
+	case status >= 500:
+		// Includes Anthropic's 529 "overloaded"
+		return ErrServer
+	default:
+		return ErrRequestFailed
+	}
+}
+
+// parseRetryAfter reads the delay requested by the server, supporting the
+// standard Retry-After header (seconds or HTTP date) and OpenAI's retry-after-ms
+func parseRetryAfter(header http.Header) time.Duration {
+	if header == nil {
+		return 0
+	}
+
+	var d time.Duration
+	if ms, err := strconv.ParseFloat(header.Get("Retry-After-Ms"), 64); err == nil {
+		d = time.Duration(ms * float64(time.Millisecond))
+	} else if value := header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+			d = time.Duration(seconds * float64(time.Second))
+		} else if date, err := http.ParseTime(value); err == nil {
+			d = time.Until(date)
+		}
+	}
+
+	if d < 0 {
+		return 0
+	}
+	if d > maxRetryAfterWait {
+		return maxRetryAfterWait
+	}
+	return d
+}
@@ -0,0 +1,121 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// doJSON sends a JSON request and decodes a JSON response into out.
+// Non-2xx responses are turned into a *statusError with the provider's own error message.
+func doJSON(ctx context.Context, client *http.Client, method, url string, headers map[string]string, body, out any) error {
+	resp, err := send(ctx, client, method, url, headers, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+// send sends a JSON request and returns the response for the caller to read.
+// Non-2xx responses are consumed and turned into a *statusError.
+func send(ctx context.Context, client *http.Client, method, url string, headers map[string]string, body any) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return nil, &statusError{
+			method:     method,
+			url:        url,
+			statusCode: resp.StatusCode,
+			status:     resp.Status,
+			message:    errorMessage(data),
+			header:     resp.Header,
+		}
+	}
+
+	return resp, nil
+}
+
+// scanLines calls fn for every non-empty line of r, stopping at the first error.
+// It is used to read streamed responses (server-sent events and NDJSON).
+func scanLines(r io.Reader, fn func(line string) error) error {
+	scanner := bufio.NewScanner(r)
+	// Streamed events are small, but allow for long lines anyway
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// errorMessage extracts a readable message from a provider error body.
+// Both Anthropic ({"error": {"message": ...}}) and Ollama ({"error": "..."}) shapes are handled.
+func errorMessage(data []byte) string {
+	var nested struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(data, &nested) == nil && nested.Error.Message != "" {
+		return nested.Error.Message
+	}
+
+	var flat struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(data, &flat) == nil && flat.Error != "" {
+		return flat.Error
+	}
+
+	return strings.TrimSpace(string(data))
+}
+
+// joinURL joins a base URL and a path without doubling slashes
+func joinURL(base, path string) string {
+	return strings.TrimRight(base, "/") + "/" + strings.TrimLeft(path, "/")
+}
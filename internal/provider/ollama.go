@@ -0,0 +1,172 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const (
+	// DefaultOllamaModel is used when no model is configured for Ollama
+	DefaultOllamaModel = "llama3.2"
+	// DefaultOllamaBaseURL is the address a local Ollama server listens on
+	DefaultOllamaBaseURL = "http://localhost:11434"
+)
+
+// ollamaProvider talks to a local or remote Ollama server through /api/chat
+type ollamaProvider struct {
+	client      *http.Client
+	model       string
+	baseURL     string
+	temperature *float64
+}
+
+type ollamaMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type ollamaOptions struct {
+	Temperature *float64 `json:"temperature,omitempty"`
+}
+
+type ollamaChatRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Stream   bool            `json:"stream"`
+	Options  *ollamaOptions  `json:"options,omitempty"`
+}
+
+type ollamaChatResponse struct {
+	Message ollamaMessage `json:"message"`
+	Done    bool          `json:"done"`
+	Error   string        `json:"error"`
+}
+
+type ollamaTagsResponse struct {
+	Models []struct {
+		Name string `json:"name"`
+	} `json:"models"`
+}
+
+func newOllama(opts Options) *ollamaProvider {
+	model := opts.Model
+	if model == "" {
+		model = DefaultOllamaModel
+	}
+
+	// Respect OLLAMA_HOST like the ollama CLI does when no base URL is configured
+	baseURL := opts.BaseURL
+	if baseURL == "" {
+		baseURL = os.Getenv("OLLAMA_HOST")
+	}
+	if baseURL == "" {
+		baseURL = DefaultOllamaBaseURL
+	}
+	// OLLAMA_HOST is commonly given as host:port without a scheme
+	if !strings.Contains(baseURL, "://") {
+		baseURL = "http://" + baseURL
+	}
+
+	return &ollamaProvider{
+		client:      opts.HTTPClient,
+		model:       model,
+		baseURL:     baseURL,
+		temperature: opts.Temperature,
+	}
+}
+
+func (p *ollamaProvider) Name() string {
+	return Ollama
+}
+
+func (p *ollamaProvider) Model() string {
+	return p.model
+}
+
+func (p *ollamaProvider) Generate(ctx context.Context, req Request) (string, error) {
+	body := ollamaChatRequest{
+		Model: p.model,
+		Messages: []ollamaMessage{
+			{Role: "user", Content: req.Prompt},
+		},
+		Stream: req.OnDelta != nil,
+	}
+	if p.temperature != nil {
+		body.Options = &ollamaOptions{Temperature: p.temperature}
+	}
+
+	if req.OnDelta != nil {
+		return p.stream(ctx, body, req.OnDelta)
+	}
+
+	var resp ollamaChatResponse
+	if err := doJSON(ctx, p.client, http.MethodPost, joinURL(p.baseURL, "/api/chat"), nil, body, &resp); err != nil {
+		return "", err
+	}
+
+	if resp.Message.Content == "" {
+		return "", ErrEmptyResponse
+	}
+	return resp.Message.Content, nil
+}
+
+// stream reads the newline-delimited JSON chunks Ollama sends when streaming
+func (p *ollamaProvider) stream(ctx context.Context, body ollamaChatRequest, onDelta func(string)) (string, error) {
+	resp, err := send(ctx, p.client, http.MethodPost, joinURL(p.baseURL, "/api/chat"), nil, body)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var text strings.Builder
+	err = scanLines(resp.Body, func(line string) error {
+		var chunk ollamaChatResponse
+		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+			return err
+		}
+		if chunk.Error != "" {
+			return errors.New(chunk.Error)
+		}
+		if chunk.Message.Content != "" {
+			text.WriteString(chunk.Message.Content)
+			onDelta(chunk.Message.Content)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if text.Len() == 0 {
+		return "", ErrEmptyResponse
+	}
+	return text.String(), nil
+}
+
+// ollamaModelName adds the tag Ollama assumes for model names without one,
+// e.g. llama3.2 is llama3.2:latest. A colon followed by a slash belongs to a
+// registry port, not a tag.
+func ollamaModelName(model string) string {
+	if i := strings.LastIndex(model, ":"); i < 0 || strings.Contains(model[i:], "/") {
+		return model + ":latest"
+	}
+	return model
+}
+
+// ListModels returns the models pulled on the Ollama server
+func (p *ollamaProvider) ListModels(ctx context.Context) ([]string, error) {
+	var resp ollamaTagsResponse
+	if err := doJSON(ctx, p.client, http.MethodGet, joinURL(p.baseURL, "/api/tags"), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+
+	models := make([]string, 0, len(resp.Models))
+	for _, model := range resp.Models {
+		models = append(models, model.Name)
+	}
+	return models, nil
+}
@@ -0,0 +1,191 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestOllamaRequest(t *testing.T) {
+	temperature := 0.2
+	tests := []struct {
+		name    string
+		onDelta func(string)
+		reply   string
+	}{
+		{"single response", nil, `{"message": {"role": "assistant", "content": "feat: add ollama"}, "done": true}`},
+		{"stream", func(string) {}, "{\"message\": {\"role\": \"assistant\", \"content\": \"feat: add \"}, \"done\": false}\n" +
+			"{\"message\": {\"role\": \"assistant\", \"content\": \"ollama\"}, \"done\": false}\n" +
+			"{\"message\": {\"role\": \"assistant\", \"content\": \"\"}, \"done\": true}\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got map[string]any
+			srv := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.URL.Path != "/api/chat" {
+					t.Errorf("got %s %s, want POST /api/chat", r.Method, r.URL.Path)
+				}
+				if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+					t.Error(err)
+				}
+				fmt.Fprint(w, tt.reply)
+			})
+
+			p, err := New(Ollama, Options{BaseURL: srv.URL + "/", Model: "qwen2.5-coder", Temperature: &temperature})
+			if err != nil {
+				t.Fatal(err)
+			}
+			message, err := p.Generate(context.Background(), Request{Prompt: "the diff", OnDelta: tt.onDelta})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if message != "feat: add ollama" {
+				t.Errorf("message = %q", message)
+			}
+
+			// stream must always be sent, as Ollama streams when it is missing
+			if stream, ok := got["stream"]; !ok || stream != (tt.onDelta != nil) {
+				t.Errorf("stream = %v (present: %v), want %v", stream, ok, tt.onDelta != nil)
+			}
+			if got["model"] != "qwen2.5-coder" {
+				t.Errorf("model = %v", got["model"])
+			}
+			messages, _ := got["messages"].([]any)
+			if len(messages) != 1 || fmt.Sprint(messages[0]) != "map[content:the diff role:user]" {
+				t.Errorf("messages = %v, want the prompt as a single user message", got["messages"])
+			}
+			if options, _ := got["options"].(map[string]any); options["temperature"] != temperature {
+				t.Errorf("options = %v, want temperature %v", got["options"], temperature)
+			}
+		})
+	}
+}
+
+func TestOllamaStreamDeltas(t *testing.T) {
+	srv := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"message": {"role": "assistant", "content": "fix: "}, "done": false}`)
+		fmt.Fprintln(w, `{"message": {"role": "assistant", "content": "typo"}, "done": true}`)
+	})
+
+	p, err := New(Ollama, Options{BaseURL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var deltas []string
+	if _, err := p.Generate(context.Background(), Request{Prompt: "diff", OnDelta: func(d string) { deltas = append(deltas, d) }}); err != nil {
+		t.Fatal(err)
+	}
+	if len(deltas) != 2 || deltas[0] != "fix: " || deltas[1] != "typo" {
+		t.Errorf("deltas = %q", deltas)
+	}
+}
+
+func TestOllamaStreamError(t *testing.T) {
+	srv := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"error": "model ran out of memory"}`)
+	})
+
+	p, err := New(Ollama, Options{BaseURL: srv.URL, Retry: &RetryPolicy{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.Generate(context.Background(), Request{Prompt: "diff", OnDelta: func(string) {}})
+	if err == nil || !errors.Is(err, ErrRequestFailed) {
+		t.Errorf("err = %v, want %v", err, ErrRequestFailed)
+	}
+}
+
+func TestOllamaEmptyResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		onDelta func(string)
+	}{
+		{"single response", `{"message": {"role": "assistant", "content": ""}, "done": true}`, nil},
+		{"stream", `{"message": {"role": "assistant", "content": ""}, "done": true}` + "\n", func(string) {}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, tt.body)
+			})
+
+			p, err := New(Ollama, Options{BaseURL: srv.URL})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := p.Generate(context.Background(), Request{Prompt: "diff", OnDelta: tt.onDelta}); !errors.Is(err, ErrEmptyResponse) {
+				t.Errorf("err = %v, want %v", err, ErrEmptyResponse)
+			}
+		})
+	}
+}
+
+func TestOllamaListModels(t *testing.T) {
+	srv := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/api/tags" {
+			t.Errorf("got %s %s, want GET /api/tags", r.Method, r.URL.Path)
+		}
+		fmt.Fprint(w, `{"models": [{"name": "llama3.2:latest"}, {"name": "qwen2.5-coder:7b"}]}`)
+	})
+
+	p, err := New(Ollama, Options{BaseURL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	models, err := p.ListModels(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(models) != 2 || models[0] != "llama3.2:latest" || models[1] != "qwen2.5-coder:7b" {
+		t.Errorf("models = %q", models)
+	}
+}
+
+func TestOllamaHost(t *testing.T) {
+	tests := []struct {
+		host string
+		want string
+	}{
+		{"", DefaultOllamaBaseURL},
+		{"127.0.0.1:9999", "http://127.0.0.1:9999"},
+		{"https://ollama.example.com", "https://ollama.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			t.Setenv("OLLAMA_HOST", tt.host)
+			if got := newOllama(Options{}).baseURL; got != tt.want {
+				t.Errorf("baseURL = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSameModel(t *testing.T) {
+	tests := []struct {
+		provider string
+		a, b     string
+		want     bool
+	}{
+		{Ollama, "llama3.2", "llama3.2:latest", true},
+		{Ollama, "llama3.2:latest", "llama3.2:latest", true},
+		{Ollama, "llama3.2", "llama3.2:1b", false},
+		{Ollama, "llama3.2", "llama3.2-vision:latest", false},
+		{Ollama, "localhost:5000/team/coder", "localhost:5000/team/coder:latest", true},
+		{Ollama, "localhost:5000/team/coder", "localhost:5000/team/coder:7b", false},
+		{OpenAI, "gpt-4o", "gpt-4o", true},
+		{OpenAI, "gpt-4o", "gpt-4o:latest", false},
+	}
+
+	for _, tt := range tests {
+		if got := SameModel(tt.provider, tt.a, tt.b); got != tt.want {
+			t.Errorf("SameModel(%s, %q, %q) = %v, want %v", tt.provider, tt.a, tt.b, got, tt.want)
+		}
+	}
+}
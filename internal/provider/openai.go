@@ -0,0 +1,141 @@
+package provider
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+)
+
+// DefaultOpenAIModel is used when no model is configured for OpenAI
+const DefaultOpenAIModel = openai.ChatModelGPT4o
+
+// DefaultAzureAPIVersion is the Azure OpenAI API version used when none is configured
+const DefaultAzureAPIVersion = "2024-06-01"
+
+// openAIProvider talks to the OpenAI Chat Completions API (or any compatible server,
+// including Azure OpenAI) through the official SDK
+type openAIProvider struct {
+	client      *openai.Client
+	model       string
+	temperature *float64
+	// modelsOptions are extra options for listing models, needed because Azure
+	// serves them outside of the deployment path
+	modelsOptions []option.RequestOption
+}
+
+func newOpenAI(opts Options) *openAIProvider {
+	model := opts.Model
+	if model == "" {
+		model = DefaultOpenAIModel
+	}
+
+	requestOptions := []option.RequestOption{
+		option.WithAPIKey(opts.APIKey),
+		option.WithHTTPClient(opts.HTTPClient),
+		// Retries are handled uniformly for all providers by the retrying wrapper
+		option.WithMaxRetries(0),
+	}
+	var modelsOptions []option.RequestOption
+
+	if opts.AzureDeployment != "" {
+		// Azure routes requests by deployment, versions the API with a query
+		// parameter and authenticates with an api-key header instead of a bearer token
+		apiVersion := opts.AzureAPIVersion
+		if apiVersion == "" {
+			apiVersion = DefaultAzureAPIVersion
+		}
+		endpoint := joinURL(opts.BaseURL, "openai") + "/"
+		requestOptions = append(requestOptions,
+			option.WithBaseURL(endpoint+"deployments/"+url.PathEscape(opts.AzureDeployment)+"/"),
+			option.WithQuery("api-version", apiVersion),
+			option.WithHeaderDel("authorization"),
+			option.WithHeader("api-key", opts.APIKey),
+		)
+		modelsOptions = append(modelsOptions, option.WithBaseURL(endpoint))
+	} else if opts.BaseURL != "" {
+		// The SDK resolves paths relative to the base URL, which therefore needs a trailing slash
+		requestOptions = append(requestOptions, option.WithBaseURL(strings.TrimRight(opts.BaseURL, "/")+"/"))
+	}
+
+	return &openAIProvider{
+		client:        openai.NewClient(requestOptions...),
+		model:         model,
+		temperature:   opts.Temperature,
+		modelsOptions: modelsOptions,
+	}
+}
+
+func (p *openAIProvider) Name() string {
+	return OpenAI
+}
+
+func (p *openAIProvider) Model() string {
+	return p.model
+}
+
+func (p *openAIProvider) Generate(ctx context.Context, req Request) (string, error) {
+	params := openai.ChatCompletionNewParams{
+		Messages: openai.F([]openai.ChatCompletionMessageParamUnion{
+			openai.UserMessage(req.Prompt),
+		}),
+		Model: openai.F(p.model),
+	}
+	if p.temperature != nil {
+		params.Temperature = openai.F(*p.temperature)
+	}
+
+	if req.OnDelta != nil {
+		return p.stream(ctx, params, req.OnDelta)
+	}
+
+	chatCompletion, err := p.client.Chat.Completions.New(ctx, params)
+	if err != nil {
+		return "", err
+	}
+
+	if len(chatCompletion.Choices) == 0 {
+		return "", ErrEmptyResponse
+	}
+	return chatCompletion.Choices[0].Message.Content, nil
+}
+
+// stream reads the completion as server-sent events, reporting each chunk to onDelta
+func (p *openAIProvider) stream(ctx context.Context, params openai.ChatCompletionNewParams, onDelta func(string)) (string, error) {
+	stream := p.client.Chat.Completions.NewStreaming(ctx, params)
+	defer stream.Close()
+
+	var text strings.Builder
+	for stream.Next() {
+		chunk := stream.Current()
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+		if delta := chunk.Choices[0].Delta.Content; delta != "" {
+			text.WriteString(delta)
+			onDelta(delta)
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return "", err
+	}
+
+	if text.Len() == 0 {
+		return "", ErrEmptyResponse
+	}
+	return text.String(), nil
+}
+
+func (p *openAIProvider) ListModels(ctx context.Context) ([]string, error) {
+	var models []string
+	iter := p.client.Models.ListAutoPaging(ctx, p.modelsOptions...)
+	for iter.Next() {
+		models = append(models, iter.Current().ID)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	return models, nil
+}
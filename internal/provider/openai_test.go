@@ -0,0 +1,207 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// openAIReply is a Chat Completions response with a single choice
+const openAIReply = `{"id": "1", "object": "chat.completion", "model": "gpt-4o", "choices": [{"index": 0, "message": {"role": "assistant", "content": "feat: add openai"}, "finish_reason": "stop"}]}`
+
+func TestOpenAIRequest(t *testing.T) {
+	var got map[string]any
+	srv := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("got %s %s, want POST /v1/chat/completions", r.Method, r.URL.Path)
+		}
+		if v := r.Header.Get("Authorization"); v != "Bearer test-key" {
+			t.Errorf("Authorization = %q, want the bearer token", v)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, openAIReply)
+	})
+
+	p, err := New(OpenAI, Options{APIKey: "test-key", BaseURL: srv.URL + "/v1", Model: "gpt-test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	message, err := p.Generate(context.Background(), Request{Prompt: "the diff"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if message != "feat: add openai" {
+		t.Errorf("message = %q", message)
+	}
+	if got["model"] != "gpt-test" {
+		t.Errorf("model = %v", got["model"])
+	}
+	if _, ok := got["temperature"]; ok {
+		t.Errorf("temperature = %v, want it left to the provider", got["temperature"])
+	}
+	// The SDK sends the prompt as a single text content part
+	messages, _ := got["messages"].([]any)
+	if len(messages) != 1 || fmt.Sprint(messages[0]) != "map[content:[map[text:the diff type:text]] role:user]" {
+		t.Errorf("messages = %v, want the prompt as a single user message", got["messages"])
+	}
+}
+
+func TestOpenAIStream(t *testing.T) {
+	var stream any
+	srv := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		stream = body["stream"]
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, delta := range []string{"fix: ", "", "stream"} {
+			fmt.Fprintf(w, "data: {\"id\": \"1\", \"object\": \"chat.completion.chunk\", \"choices\": [{\"index\": 0, \"delta\": {\"content\": %q}}]}\n\n", delta)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+
+	p, err := New(OpenAI, Options{APIKey: "test-key", BaseURL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var deltas []string
+	message, err := p.Generate(context.Background(), Request{Prompt: "diff", OnDelta: func(d string) { deltas = append(deltas, d) }})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stream != true {
+		t.Errorf("stream = %v, want true", stream)
+	}
+	if message != "fix: stream" || len(deltas) != 2 {
+		t.Errorf("message = %q, deltas = %q", message, deltas)
+	}
+}
+
+func TestOpenAIEmptyResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		onDelta func(string)
+	}{
+		{"no choices", `{"id": "1", "object": "chat.completion", "choices": []}`, nil},
+		{"empty stream", "data: [DONE]\n\n", func(string) {}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+				if tt.onDelta == nil {
+					w.Header().Set("Content-Type", "application/json")
+				}
+				fmt.Fprint(w, tt.body)
+			})
+
+			p, err := New(OpenAI, Options{APIKey: "test-key", BaseURL: srv.URL})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := p.Generate(context.Background(), Request{Prompt: "diff", OnDelta: tt.onDelta}); !errors.Is(err, ErrEmptyResponse) {
+				t.Errorf("err = %v, want %v", err, ErrEmptyResponse)
+			}
+		})
+	}
+}
+
+func TestOpenAIBaseURL(t *testing.T) {
+	for _, suffix := range []string{"/v1", "/v1/"} {
+		t.Run(suffix, func(t *testing.T) {
+			var path string
+			srv := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+				path = r.URL.Path
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(w, openAIReply)
+			})
+
+			p, err := New(OpenAI, Options{APIKey: "test-key", BaseURL: srv.URL + suffix})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := p.Generate(context.Background(), Request{Prompt: "diff"}); err != nil {
+				t.Fatal(err)
+			}
+			if path != "/v1/chat/completions" {
+				t.Errorf("path = %q, want /v1/chat/completions", path)
+			}
+		})
+	}
+}
+
+func TestAzureOpenAI(t *testing.T) {
+	tests := []struct {
+		apiVersion string
+		want       string
+	}{
+		{"", DefaultAzureAPIVersion},
+		{"2025-01-01-preview", "2025-01-01-preview"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			var got *http.Request
+			srv := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+				got = r
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(w, openAIReply)
+			})
+
+			p, err := New(OpenAI, Options{
+				APIKey:          "azure-key",
+				BaseURL:         srv.URL + "/",
+				AzureDeployment: "my gpt",
+				AzureAPIVersion: tt.apiVersion,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := p.Generate(context.Background(), Request{Prompt: "diff"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if got.URL.EscapedPath() != "/openai/deployments/my%20gpt/chat/completions" {
+				t.Errorf("path = %q", got.URL.EscapedPath())
+			}
+			if v := got.URL.Query().Get("api-version"); v != tt.want {
+				t.Errorf("api-version = %q, want %q", v, tt.want)
+			}
+			if v := got.Header.Get("api-key"); v != "azure-key" {
+				t.Errorf("api-key = %q, want azure-key", v)
+			}
+			if v := got.Header.Get("Authorization"); v != "" {
+				t.Errorf("Authorization = %q, want none", v)
+			}
+		})
+	}
+}
+
+func TestAzureOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		opts     Options
+	}{
+		{"other provider", Anthropic, Options{BaseURL: "https://example.openai.azure.com", AzureDeployment: "gpt"}},
+		{"no endpoint", OpenAI, Options{AzureDeployment: "gpt"}},
+		{"relative base URL", OpenAI, Options{BaseURL: "example.openai.azure.com", AzureDeployment: "gpt"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.provider, tt.opts); err == nil {
+				t.Error("New succeeded, want an error")
+			}
+		})
+	}
+}
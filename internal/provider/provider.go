@@ -0,0 +1,193 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Names of the supported AI providers
+const (
+	OpenAI    = "openai"
+	Anthropic = "anthropic"
+	Ollama    = "ollama"
+)
+
+var (
+	// ErrUnknownProvider is returned when no implementation exists for the requested provider
+	ErrUnknownProvider = errors.New("unknown AI provider")
+	// ErrEmptyResponse is returned when the provider answers without any generated text
+	ErrEmptyResponse = errors.New("provider returned an empty response")
+)
+
+// Request describes a single completion request sent to a provider
+type Request struct {
+	// Prompt is the fully rendered prompt sent as the user message
+	Prompt string
+	// OnDelta, when set, makes the provider stream the response and call it with
+	// each chunk of text as it arrives. Generate still returns the full text.
+	OnDelta func(delta string)
+}
+
+// Provider generates text completions from an AI backend
+type Provider interface {
+	// Name returns the provider identifier (openai, anthropic, ollama)
+	Name() string
+	// Model returns the model used for generation
+	Model() string
+	// Generate sends the request and returns the generated text
+	Generate(ctx context.Context, req Request) (string, error)
+	// ListModels returns the identifiers of the models available from the provider
+	ListModels(ctx context.Context) ([]string, error)
+}
+
+// Options configures a provider instance
+type Options struct {
+	// APIKey is the secret used to authenticate against the provider (unused by Ollama)
+	APIKey string
+	// Model overrides the provider's default model
+	Model string
+	// BaseURL overrides the provider's default API endpoint
+	BaseURL string
+	// Temperature overrides the provider's default sampling temperature when set
+	Temperature *float64
+	// Headers are added to every request, e.g. for authenticating against a gateway
+	Headers http.Header
+	// AzureDeployment switches the OpenAI provider to Azure OpenAI, targeting this deployment.
+	// BaseURL must then be the Azure resource endpoint.
+	AzureDeployment string
+	// AzureAPIVersion is the Azure OpenAI api-version query parameter
+	AzureAPIVersion string
+	// Retry controls retrying of transient failures; DefaultRetryPolicy is used when nil
+	Retry *RetryPolicy
+	// HTTPClient is used for all requests; a client with a generous timeout is used when nil
+	HTTPClient *http.Client
+}
+
+// Names returns all supported provider names
+func Names() []string {
+	return []string{OpenAI, Anthropic, Ollama}
+}
+
+// New creates the provider implementation registered under name.
+// Errors returned by the provider are *Error values, and transient failures
+// are retried according to opts.Retry.
+func New(name string, opts Options) (Provider, error) {
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: 5 * time.Minute}
+	}
+	if opts.BaseURL != "" {
+		if u, err := url.Parse(opts.BaseURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("invalid base URL %q: expected an absolute URL such as https://example.com/v1", opts.BaseURL)
+		}
+	}
+	if opts.AzureDeployment != "" {
+		if name != OpenAI {
+			return nil, fmt.Errorf("azure deployments are only supported by the openai provider, not %s", name)
+		}
+		if opts.BaseURL == "" {
+			return nil, errors.New("azure mode requires the base URL to be set to the Azure OpenAI endpoint")
+		}
+	}
+	if len(opts.Headers) > 0 {
+		// Wrap the client so every provider sends the extra headers the same way
+		client := *opts.HTTPClient
+		client.Transport = &headerTransport{base: client.Transport, headers: opts.Headers}
+		opts.HTTPClient = &client
+	}
+
+	policy := DefaultRetryPolicy
+	if opts.Retry != nil {
+		policy = *opts.Retry
+	}
+
+	var p Provider
+	switch name {
+	case OpenAI:
+		p = newOpenAI(opts)
+	case Anthropic:
+		p = newAnthropic(opts)
+	case Ollama:
+		p = newOllama(opts)
+	default:
+		return nil, fmt.Errorf("%w: %q (expected one of openai, anthropic, ollama)", ErrUnknownProvider, name)
+	}
+	return &retrying{Provider: p, policy: policy}, nil
+}
+
+// ParseHeaders parses "Name: Value" strings into HTTP headers
+func ParseHeaders(values []string) (http.Header, error) {
+	headers := make(http.Header)
+	for _, value := range values {
+		name, v, ok := strings.Cut(value, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid header %q: expected \"Name: Value\"", value)
+		}
+		headers.Add(name, strings.TrimSpace(v))
+	}
+	return headers, nil
+}
+
+// headerTransport adds a fixed set of headers to every request
+type headerTransport struct {
+	base    http.RoundTripper
+	headers http.Header
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTrippers must not modify the original request
+	req = req.Clone(req.Context())
+	for name, values := range t.headers {
+		req.Header[http.CanonicalHeaderKey(name)] = values
+	}
+
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
+
+// SameModel reports whether two model names of the provider refer to the same model
+func SameModel(name, a, b string) bool {
+	if name == Ollama {
+		return ollamaModelName(a) == ollamaModelName(b)
+	}
+	return a == b
+}
+
+// RequiresAPIKey reports whether the provider needs an API key to be configured
+func RequiresAPIKey(name string) bool {
+	return name != Ollama
+}
+
+// APIKeyEnv returns the environment variable conventionally holding the provider's API key
+func APIKeyEnv(name string) string {
+	switch name {
+	case OpenAI:
+		return "OPENAI_API_KEY"
+	case Anthropic:
+		return "ANTHROPIC_API_KEY"
+	default:
+		return ""
+	}
+}
+
+// DisplayName returns a human readable provider name for use in the UI
+func DisplayName(name string) string {
+	switch name {
+	case OpenAI:
+		return "OpenAI"
+	case Anthropic:
+		return "Anthropic"
+	case Ollama:
+		return "Ollama"
+	default:
+		return name
+	}
+}
@@ -0,0 +1,70 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newServer starts a test server running handler for the duration of the test
+func newServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestParseHeaders(t *testing.T) {
+	headers, err := ParseHeaders([]string{"X-Team: platform", "x-trace:  abc ", "X-Team: tools"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := headers.Values("X-Team"); len(got) != 2 || got[0] != "platform" || got[1] != "tools" {
+		t.Errorf("X-Team = %q", got)
+	}
+	if got := headers.Get("X-Trace"); got != "abc" {
+		t.Errorf("X-Trace = %q, want abc", got)
+	}
+
+	for _, value := range []string{"no colon", ": value"} {
+		if _, err := ParseHeaders([]string{value}); err == nil {
+			t.Errorf("ParseHeaders(%q) succeeded, want an error", value)
+		}
+	}
+}
+
+func TestExtraHeaders(t *testing.T) {
+	replies := map[string]string{
+		OpenAI:    openAIReply,
+		Anthropic: `{"content": [{"type": "text", "text": "feat: add headers"}]}`,
+		Ollama:    `{"message": {"role": "assistant", "content": "feat: add headers"}, "done": true}`,
+	}
+
+	for _, name := range Names() {
+		t.Run(name, func(t *testing.T) {
+			var header http.Header
+			srv := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+				header = r.Header
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(w, replies[name])
+			})
+
+			headers := http.Header{"X-Gateway-Key": {"gw-123"}, "X-Team": {"platform", "tools"}}
+			p, err := New(name, Options{APIKey: "test-key", BaseURL: srv.URL, Headers: headers})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := p.Generate(context.Background(), Request{Prompt: "diff"}); err != nil {
+				t.Fatal(err)
+			}
+			if got := header.Get("X-Gateway-Key"); got != "gw-123" {
+				t.Errorf("X-Gateway-Key = %q, want gw-123", got)
+			}
+			if got := header.Values("X-Team"); len(got) != 2 {
+				t.Errorf("X-Team = %q, want both values", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package provider
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy controls how failed requests are retried
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt (0 disables retrying)
+	MaxRetries int
+	// InitialBackoff is the delay before the first retry, doubled for each further retry
+	InitialBackoff time.Duration
+	// MaxBackoff caps the exponential backoff (a server's Retry-After is honored regardless)
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy is used when no policy is configured
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries:     2,
+	InitialBackoff: time.Second,
+	MaxBackoff:     30 * time.Second,
+}
+
+// retrying wraps a provider, classifying its errors and retrying transient failures
+type retrying struct {
+	Provider
+	policy RetryPolicy
+}
+
+func (r *retrying) Generate(ctx context.Context, req Request) (string, error) {
+	// A stream that already produced output can't be transparently restarted,
+	// as the caller has already shown the partial text
+	streamed := false
+	if onDelta := req.OnDelta; onDelta != nil {
+		req.OnDelta = func(delta string) {
+			streamed = true
+			onDelta(delta)
+		}
+	}
+
+	var response string
+	err := r.do(ctx, func() error {
+		var err error
+		response, err = r.Provider.Generate(ctx, req)
+		return err
+	}, func() bool { return !streamed })
+	return response, err
+}
+
+func (r *retrying) ListModels(ctx context.Context) ([]string, error) {
+	var models []string
+	err := r.do(ctx, func() error {
+		var err error
+		models, err = r.Provider.ListModels(ctx)
+		return err
+	}, func() bool { return true })
+	return models, err
+}
+
+// do runs attempt until it succeeds, fails permanently, or retries are exhausted.
+// canRetry is consulted before each retry.
+func (r *retrying) do(ctx context.Context, attempt func() error, canRetry func() bool) error {
+	for retry := 0; ; retry++ {
+		err := classify(r.Name(), attempt())
+		if err == nil {
+			return nil
+		}
+
+		perr, ok := err.(*Error)
+		if !ok || !perr.Retryable() || retry >= r.policy.MaxRetries || !canRetry() {
+			return err
+		}
+
+		if err := sleepContext(ctx, r.backoff(retry, perr.RetryAfter)); err != nil {
+			return err
+		}
+	}
+}
+
+// backoff returns the delay before the given retry (0-based)
+func (r *retrying) backoff(retry int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return retryAfter
+	}
+
+	d := r.policy.InitialBackoff << retry
+	if r.policy.MaxBackoff > 0 && (d > r.policy.MaxBackoff || d <= 0) {
+		d = r.policy.MaxBackoff
+	}
+	// Add up to 20% jitter so concurrent clients don't retry in lockstep
+	if d > 0 {
+		d += time.Duration(rand.Int63n(int64(d)/5 + 1))
+	}
+	return d
+}
+
+// sleepContext waits for d, returning early with the context's error if it is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
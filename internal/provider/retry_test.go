@@ -0,0 +1,290 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testPolicy retries quickly so tests don't wait on real backoff
+var testPolicy = RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+
+// newTestProvider creates a provider talking to a test server running handler
+func newTestProvider(t *testing.T, name string, handler http.HandlerFunc) Provider {
+	t.Helper()
+	srv := newServer(t, handler)
+	policy := testPolicy
+	p, err := New(name, Options{APIKey: "test-key", BaseURL: srv.URL, Retry: &policy})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// failTimes answers the first n requests with status and body, then succeeds
+// with an Ollama chat response. It counts every request in calls.
+func failTimes(n int, status int, header http.Header, body string, calls *atomic.Int32) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if int(calls.Add(1)) <= n {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(status)
+			fmt.Fprint(w, body)
+			return
+		}
+		fmt.Fprint(w, `{"message": {"role": "assistant", "content": "feat: add retries"}, "done": true}`)
+	}
+}
+
+func TestErrorClassification(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		kind   error
+	}{
+		{"unauthorized", http.StatusUnauthorized, `{"error": {"message": "invalid x-api-key"}}`, ErrAuth},
+		{"forbidden", http.StatusForbidden, `{"error": "forbidden"}`, ErrAuth},
+		{"insufficient quota", http.StatusTooManyRequests, `{"error": {"message": "You exceeded your current quota", "code": "insufficient_quota"}}`, ErrQuotaExceeded},
+		{"credit balance", http.StatusBadRequest, `{"error": {"message": "Your credit balance is too low"}}`, ErrQuotaExceeded},
+		{"context length", http.StatusBadRequest, `{"error": {"message": "This model's maximum context length is 8192 tokens"}}`, ErrContextLength},
+		{"prompt too long", http.StatusBadRequest, `{"error": {"message": "prompt is too long: 210000 tokens > 200000 maximum"}}`, ErrContextLength},
+		{"payload too large", http.StatusRequestEntityTooLarge, `request too large`, ErrContextLength},
+		{"model not found", http.StatusNotFound, `{"error": "model \"llama9\" not found, try pulling it first"}`, ErrModelNotFound},
+		{"bad request", http.StatusBadRequest, `{"error": "invalid options"}`, ErrRequestFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			p := newTestProvider(t, Ollama, failTimes(100, tt.status, nil, tt.body, &calls))
+
+			_, err := p.Generate(context.Background(), Request{Prompt: "diff"})
+			if !errors.Is(err, tt.kind) {
+				t.Fatalf("err = %v, want %v", err, tt.kind)
+			}
+			var perr *Error
+			if !errors.As(err, &perr) || perr.StatusCode != tt.status || perr.Provider != Ollama {
+				t.Errorf("err = %#v, want an *Error for HTTP %d from %s", err, tt.status, Ollama)
+			}
+			if got := calls.Load(); got != 1 {
+				t.Errorf("got %d requests, want 1 as %v is permanent", got, tt.kind)
+			}
+		})
+	}
+}
+
+func TestRetryTransientFailures(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		header http.Header
+	}{
+		{"rate limited", http.StatusTooManyRequests, nil},
+		{"retry-after seconds", http.StatusTooManyRequests, http.Header{"Retry-After": {"0.01"}}},
+		{"retry-after-ms", http.StatusTooManyRequests, http.Header{"Retry-After-Ms": {"10"}}},
+		{"internal server error", http.StatusInternalServerError, nil},
+		{"bad gateway", http.StatusBadGateway, nil},
+		{"overloaded", 529, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			p := newTestProvider(t, Ollama, failTimes(2, tt.status, tt.header, `{"error": "try again"}`, &calls))
+
+			got, err := p.Generate(context.Background(), Request{Prompt: "diff"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != "feat: add retries" {
+				t.Errorf("got %q", got)
+			}
+			if n := calls.Load(); n != 3 {
+				t.Errorf("got %d requests, want 3", n)
+			}
+		})
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	var calls atomic.Int32
+	p := newTestProvider(t, Ollama, failTimes(100, http.StatusServiceUnavailable, nil, `{"error": "unavailable"}`, &calls))
+
+	_, err := p.Generate(context.Background(), Request{Prompt: "diff"})
+	if !errors.Is(err, ErrServer) {
+		t.Errorf("err = %v, want %v", err, ErrServer)
+	}
+	if n := calls.Load(); n != int32(testPolicy.MaxRetries)+1 {
+		t.Errorf("got %d requests, want %d", n, testPolicy.MaxRetries+1)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+	}{
+		{"none", http.Header{}, 0},
+		{"seconds", http.Header{"Retry-After": {"3"}}, 3 * time.Second},
+		{"milliseconds win", http.Header{"Retry-After": {"3"}, "Retry-After-Ms": {"250"}}, 250 * time.Millisecond},
+		{"capped", http.Header{"Retry-After": {"3600"}}, maxRetryAfterWait},
+		{"past date", http.Header{"Retry-After": {"Wed, 21 Oct 2015 07:28:00 GMT"}}, 0},
+		{"garbage", http.Header{"Retry-After": {"soon"}}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRetryAfter(tt.header); got != tt.want {
+				t.Errorf("parseRetryAfter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNoRetryAfterStreamedOutput(t *testing.T) {
+	var calls atomic.Int32
+	p := newTestProvider(t, Ollama, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		fmt.Fprintln(w, `{"message": {"role": "assistant", "content": "feat: "}, "done": false}`)
+		w.(http.Flusher).Flush()
+		// Drop the connection mid-stream
+		panic(http.ErrAbortHandler)
+	})
+
+	var deltas []string
+	_, err := p.Generate(context.Background(), Request{
+		Prompt:  "diff",
+		OnDelta: func(delta string) { deltas = append(deltas, delta) },
+	})
+	if !errors.Is(err, ErrNetwork) {
+		t.Errorf("err = %v, want %v", err, ErrNetwork)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("got %d requests, want 1 after output was streamed", n)
+	}
+	if len(deltas) != 1 {
+		t.Errorf("deltas = %q, want the single chunk sent before the failure", deltas)
+	}
+}
+
+func TestRetryStreamWithoutOutput(t *testing.T) {
+	var calls atomic.Int32
+	p := newTestProvider(t, Ollama, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, `{"message": {"role": "assistant", "content": "feat: retry"}, "done": true}`)
+	})
+
+	got, err := p.Generate(context.Background(), Request{Prompt: "diff", OnDelta: func(string) {}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "feat: retry" || calls.Load() != 2 {
+		t.Errorf("got %q after %d requests, want a retried stream", got, calls.Load())
+	}
+}
+
+func TestCancelWhileWaitingToRetry(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var calls atomic.Int32
+	p := newTestProvider(t, Ollama, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+		// Cancel once the provider has the response and starts waiting
+		time.AfterFunc(20*time.Millisecond, cancel)
+	})
+
+	start := time.Now()
+	_, err := p.Generate(ctx, Request{Prompt: "diff"})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want %v", err, context.Canceled)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("took %v, want the wait to stop on cancellation", elapsed)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("got %d requests, want 1", n)
+	}
+}
+
+func TestRetryOpenAIRateLimit(t *testing.T) {
+	var calls atomic.Int32
+	p := newTestProvider(t, OpenAI, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("retry-after-ms", "10")
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"error": {"message": "Rate limit reached for gpt-4o", "type": "requests"}}`)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": "1", "object": "chat.completion", "model": "gpt-4o", "choices": [{"index": 0, "message": {"role": "assistant", "content": "fix: retry"}, "finish_reason": "stop"}]}`)
+	})
+
+	got, err := p.Generate(context.Background(), Request{Prompt: "diff"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "fix: retry" || calls.Load() != 2 {
+		t.Errorf("got %q after %d requests, want the rate limited request retried", got, calls.Load())
+	}
+}
+
+func TestRetryAnthropicStreamError(t *testing.T) {
+	const (
+		delta = "event: content_block_delta\ndata: {\"type\": \"content_block_delta\", \"delta\": {\"type\": \"text_delta\", \"text\": \"feat: retry\"}}\n\n"
+		start = "event: message_start\ndata: {\"type\": \"message_start\"}\n\n"
+	)
+	errorEvent := func(kind string) string {
+		return fmt.Sprintf("event: error\ndata: {\"type\": \"error\", \"error\": {\"type\": %q, \"message\": \"failed\"}}\n\n", kind)
+	}
+
+	tests := []struct {
+		name  string
+		first string
+		calls int32
+		err   error
+	}{
+		{"overloaded before output", start + errorEvent("overloaded_error"), 2, nil},
+		{"rate limited before output", errorEvent("rate_limit_error"), 2, nil},
+		{"overloaded after output", delta + errorEvent("overloaded_error"), 1, ErrServer},
+		{"authentication", errorEvent("authentication_error"), 1, ErrAuth},
+		{"unknown type", errorEvent("something_new"), 1, ErrRequestFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			p := newTestProvider(t, Anthropic, func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) == 1 {
+					fmt.Fprint(w, tt.first)
+					return
+				}
+				fmt.Fprint(w, delta)
+			})
+
+			got, err := p.Generate(context.Background(), Request{Prompt: "diff", OnDelta: func(string) {}})
+			if !errors.Is(err, tt.err) {
+				t.Errorf("err = %v, want %v", err, tt.err)
+			}
+			if tt.err == nil && got != "feat: retry" {
+				t.Errorf("message = %q, want the retried stream", got)
+			}
+			if n := calls.Load(); n != tt.calls {
+				t.Errorf("got %d requests, want %d", n, tt.calls)
+			}
+		})
+	}
+}
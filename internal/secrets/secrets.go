@@ -0,0 +1,246 @@
+package secrets
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hamzabow/co/internal/gitdiff"
+)
+
+// Modes for handling secrets found in the staged diff
+const (
+	// ModeRedact replaces secrets before the diff is sent
+	ModeRedact = "redact"
+	// ModeBlock refuses to send a diff containing secrets
+	ModeBlock = "block"
+	// ModeOff sends the diff unchanged
+	ModeOff = "off"
+)
+
+// Modes returns the supported modes
+func Modes() []string {
+	return []string{ModeRedact, ModeBlock, ModeOff}
+}
+
+// Kinds of detected secrets
+const (
+	KindAWSAccessKey = "AWS access key"
+	KindPrivateKey   = "private key"
+	KindJWT          = "JWT"
+	KindToken        = "API token"
+	KindHighEntropy  = "high-entropy string"
+	KindStoredAPIKey = "stored API key"
+)
+
+// minKnownSecretLength keeps short stored values from redacting ordinary text
+const minKnownSecretLength = 8
+
+// Finding is a possible secret in a diff
+type Finding struct {
+	// Path is the file the secret was found in
+	Path string
+	// Line is the line number in the new version of the file, or the old one for removed lines
+	Line int
+	// Kind describes what was detected
+	Kind string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s:%d (%s)", f.Path, f.Line, f.Kind)
+}
+
+// pattern detects one kind of secret
+type pattern struct {
+	kind string
+	re   *regexp.Regexp
+	// group is the submatch holding the secret, 0 for the whole match
+	group int
+	// minEntropy, when set, filters out matches that don't look random
+	minEntropy float64
+	// skipHex filters out hex strings, which are mostly hashes such as commit ids
+	skipHex bool
+}
+
+var patterns = []pattern{
+	{kind: KindAWSAccessKey, re: regexp.MustCompile(`\b(?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16}\b`)},
+	{kind: KindJWT, re: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`)},
+	{kind: KindToken, re: regexp.MustCompile(`\b(?:sk-(?:ant-|proj-)?[A-Za-z0-9_-]{20,}|gh[pousr]_[A-Za-z0-9]{30,}|github_pat_[A-Za-z0-9_]{30,}|xox[abprs]-[A-Za-z0-9-]{10,}|AIza[0-9A-Za-z_-]{35}|(?:sk|rk)_live_[0-9A-Za-z]{20,}|glpat-[0-9A-Za-z_-]{20,})`)},
+	// Values assigned to something named like a credential need less randomness
+	// to be suspicious, and many real tokens are hex
+	{kind: KindHighEntropy, re: regexp.MustCompile(`(?i)(?:key|secret|token|passw(?:or)?d|pwd|credential|auth)[a-z0-9_.-]*["']?\s*[:=]+\s*["']?([^\s"'` + "`" + `,;]{12,})`), group: 1, minEntropy: 3.0},
+	{kind: KindHighEntropy, re: regexp.MustCompile(`[A-Za-z0-9+/_-]{24,}={0,2}`), minEntropy: 4.2, skipHex: true},
+}
+
+var (
+	// privateKeyBegin and privateKeyEnd delimit PEM encoded private keys
+	privateKeyBegin = regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----`)
+	privateKeyEnd   = regexp.MustCompile(`-----END [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----`)
+
+	// hunkHeader holds the starting line numbers of a hunk
+	hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,\d+)? \+(\d+)`)
+
+	// hexString matches hex strings such as hashes
+	hexString = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+)
+
+// Scanner finds and redacts secrets in diffs
+type Scanner struct {
+	known []string
+}
+
+// NewScanner creates a scanner that also looks for the given known secrets,
+// such as the API keys co has stored
+func NewScanner(known []string) *Scanner {
+	s := &Scanner{}
+	for _, secret := range known {
+		if len(secret) >= minKnownSecretLength {
+			s.known = append(s.known, secret)
+		}
+	}
+	return s
+}
+
+// Redact returns the files with every detected secret replaced by a
+// [REDACTED ...] marker, along with where the secrets were found
+func (s *Scanner) Redact(files []gitdiff.File) ([]gitdiff.File, []Finding) {
+	var findings []Finding
+	redacted := make([]gitdiff.File, len(files))
+
+	for i, f := range files {
+		redacted[i] = f
+		redacted[i].Hunks = make([]string, len(f.Hunks))
+		for j, hunk := range f.Hunks {
+			var found []Finding
+			redacted[i].Hunks[j], found = s.redactHunk(f.Path, hunk)
+			findings = append(findings, found...)
+		}
+	}
+
+	return redacted, findings
+}
+
+// RedactText redacts text that couldn't be parsed as a diff, reporting the
+// findings under name by their line in the text
+func (s *Scanner) RedactText(name, text string) (string, []Finding) {
+	return s.redactHunk(name, text)
+}
+
+// redactHunk redacts a single hunk, tracking line numbers from its header.
+// Text without a header is numbered from its first line.
+func (s *Scanner) redactHunk(path, hunk string) (string, []Finding) {
+	var findings []Finding
+	var out strings.Builder
+	oldLine, newLine := 1, 1
+	inPrivateKey := false
+
+	for _, line := range strings.SplitAfter(hunk, "\n") {
+		if line == "" {
+			continue
+		}
+		if m := hunkHeader.FindStringSubmatch(line); m != nil {
+			oldLine, _ = strconv.Atoi(m[1])
+			newLine, _ = strconv.Atoi(m[2])
+			out.WriteString(line)
+			continue
+		}
+
+		// Report removed lines by their old number, others by their new one
+		lineNo := newLine
+		switch line[0] {
+		case '-':
+			lineNo = oldLine
+			oldLine++
+		case '+':
+			newLine++
+		case '\\':
+			// "\ No newline at end of file"
+		default:
+			oldLine++
+			newLine++
+		}
+
+		// The lines of a PEM block are redacted as a whole
+		if inPrivateKey || privateKeyBegin.MatchString(line) {
+			if !inPrivateKey {
+				findings = append(findings, Finding{Path: path, Line: lineNo, Kind: KindPrivateKey})
+			}
+			inPrivateKey = !privateKeyEnd.MatchString(line)
+			out.WriteString(line[:1] + marker(KindPrivateKey) + "\n")
+			continue
+		}
+
+		redacted, kinds := s.redactLine(line)
+		for _, kind := range kinds {
+			findings = append(findings, Finding{Path: path, Line: lineNo, Kind: kind})
+		}
+		out.WriteString(redacted)
+	}
+
+	return out.String(), findings
+}
+
+// redactLine replaces the secrets found in a line, returning the kinds found
+func (s *Scanner) redactLine(line string) (string, []string) {
+	var kinds []string
+
+	for _, secret := range s.known {
+		if strings.Contains(line, secret) {
+			line = strings.ReplaceAll(line, secret, marker(KindStoredAPIKey))
+			kinds = append(kinds, KindStoredAPIKey)
+		}
+	}
+
+	for _, p := range patterns {
+		for _, m := range p.re.FindAllStringSubmatch(line, -1) {
+			secret := m[p.group]
+			if strings.Contains(secret, "[REDACTED") || !p.suspicious(secret) {
+				continue
+			}
+			line = strings.Replace(line, secret, marker(p.kind), 1)
+			kinds = append(kinds, p.kind)
+		}
+	}
+
+	return line, kinds
+}
+
+// suspicious reports whether a match looks random enough to be a secret
+func (p pattern) suspicious(s string) bool {
+	if p.minEntropy == 0 {
+		return true
+	}
+	// Commit ids and other hashes are random but not secret
+	if p.skipHex && hexString.MatchString(s) {
+		return false
+	}
+	// Identifiers and paths rarely mix letters and digits the way keys do
+	if !strings.ContainsAny(s, "0123456789") || strings.ToLower(s) == s && strings.ContainsAny(s, "/_") {
+		return false
+	}
+	return entropy(s) >= p.minEntropy
+}
+
+// entropy returns the Shannon entropy of s in bits per character
+func entropy(s string) float64 {
+	counts := make(map[rune]int)
+	n := 0
+	for _, r := range s {
+		counts[r]++
+		n++
+	}
+
+	var bits float64
+	for _, c := range counts {
+		p := float64(c) / float64(n)
+		bits -= p * math.Log2(p)
+	}
+	return bits
+}
+
+// marker replaces a redacted secret in the diff
+func marker(kind string) string {
+	return "[REDACTED " + kind + "]"
+}